@@ -6,12 +6,54 @@ type (
 	Builder struct {
 		model        *Model
 		fields       []string
-		wheres       [][]interface{}
+		selectRaws   []*Expression
+		wheres       []*where
 		updateFields []string
-		orders       [][]string
-		limit        int64
-		limitOffset  int64
-		tableName    string
+		sets         [][]interface{}
+		// joins {kind, table, first, operator, second}
+		joins   [][]string
+		groups  []string
+		havings []*where
+		// aggregate {function, field} of the OPCount
+		aggregate []string
+		orders    [][]string
+		// orderRaws the expressions of the raw orders, which are {"", sql} in orders
+		orderRaws   []*Expression
+		limit       int64
+		limitOffset int64
+		tableName   string
+		// fromSub the subquery of FROM, tableName is its alias
+		fromSub *SubQuery
+		unions  []*union
+		ctes    []*cte
+		// lock UPDATE or SHARE, lockOption SKIP LOCKED or NOWAIT
+		lock       string
+		lockOption string
+	}
+
+	// where a where condition, or a parenthesised group of conditions
+	where struct {
+		// or joined to the previous condition by OR instead of AND
+		or bool
+		// raw the condition is the expression of value
+		raw       bool
+		field     string
+		condition string
+		value     interface{}
+		group     *Builder
+	}
+
+	// cte a common table expression, the recursive select is combined with the anchor by UNION ALL
+	cte struct {
+		name      string
+		anchor    *SubQuery
+		recursive *SubQuery
+	}
+
+	// union a select combined by UNION or UNION ALL
+	union struct {
+		all bool
+		sub *SubQuery
 	}
 )
 
@@ -19,9 +61,15 @@ type (
 func NewBuilder() *Builder {
 	return &Builder{
 		fields:       make([]string, 0),
-		wheres:       make([][]interface{}, 0),
+		selectRaws:   make([]*Expression, 0),
+		wheres:       make([]*where, 0),
+		joins:        make([][]string, 0),
+		groups:       make([]string, 0),
+		havings:      make([]*where, 0),
 		orders:       make([][]string, 0),
+		orderRaws:    make([]*Expression, 0),
 		updateFields: make([]string, 0),
+		sets:         make([][]interface{}, 0),
 		limitOffset:  10,
 	}
 }
@@ -29,11 +77,75 @@ func NewBuilder() *Builder {
 // reset reset builder attr
 func (b *Builder) reset() {
 	b.fields = make([]string, 0)
-	b.wheres = make([][]interface{}, 0)
+	b.selectRaws = make([]*Expression, 0)
+	b.wheres = make([]*where, 0)
+	b.joins = make([][]string, 0)
+	b.groups = make([]string, 0)
+	b.havings = make([]*where, 0)
+	b.aggregate = nil
 	b.updateFields = make([]string, 0)
+	b.sets = make([][]interface{}, 0)
 	b.orders = make([][]string, 0)
+	b.orderRaws = make([]*Expression, 0)
 	b.limit = 0
 	b.limitOffset = 10
+	b.tableName = ""
+	b.fromSub = nil
+	b.unions = nil
+	b.ctes = nil
+	b.lock = ""
+	b.lockOption = ""
+}
+
+// Table the table of the query instead of the entity table
+func (b *Builder) Table(table string) {
+	b.tableName = table
+}
+
+// FromSub query the subquery as the table of the alias
+func (b *Builder) FromSub(sub *SubQuery, alias string) {
+	b.fromSub = sub
+	b.tableName = alias
+}
+
+// With the common table expression of the subquery
+func (b *Builder) With(name string, sub *SubQuery) {
+	b.ctes = append(b.ctes, &cte{name: name, anchor: sub})
+}
+
+// WithRecursive the recursive common table expression, anchor UNION ALL recursive
+func (b *Builder) WithRecursive(name string, anchor *SubQuery, recursive *SubQuery) {
+	b.ctes = append(b.ctes, &cte{name: name, anchor: anchor, recursive: recursive})
+}
+
+// Union combine the select of the subquery by UNION
+func (b *Builder) Union(sub *SubQuery) {
+	b.unions = append(b.unions, &union{sub: sub})
+}
+
+// UnionAll combine the select of the subquery by UNION ALL
+func (b *Builder) UnionAll(sub *SubQuery) {
+	b.unions = append(b.unions, &union{all: true, sub: sub})
+}
+
+// Join INNER JOIN table ON first operator second, the columns can be qualified, eg: user.id
+func (b *Builder) Join(table string, first string, operator string, second string) {
+	b.joins = append(b.joins, []string{"INNER JOIN", table, first, operator, second})
+}
+
+// LeftJoin LEFT JOIN table ON first operator second
+func (b *Builder) LeftJoin(table string, first string, operator string, second string) {
+	b.joins = append(b.joins, []string{"LEFT JOIN", table, first, operator, second})
+}
+
+// RightJoin RIGHT JOIN table ON first operator second
+func (b *Builder) RightJoin(table string, first string, operator string, second string) {
+	b.joins = append(b.joins, []string{"RIGHT JOIN", table, first, operator, second})
+}
+
+// CrossJoin CROSS JOIN table
+func (b *Builder) CrossJoin(table string) {
+	b.joins = append(b.joins, []string{"CROSS JOIN", table, "", "", ""})
 }
 
 // Select select field
@@ -42,12 +154,93 @@ func (b *Builder) Select(fields []string) error {
 	return nil
 }
 
+// SelectRaw select the raw expression after the fields
+func (b *Builder) SelectRaw(sql string, bindings ...interface{}) {
+	b.selectRaws = append(b.selectRaws, Expr(sql, bindings...))
+}
+
 // WhereCondition where condition
 func (b *Builder) WhereCondition(field string, condition string, value interface{}) error {
-	b.wheres = append(b.wheres, []interface{}{field, condition, value})
+	b.wheres = append(b.wheres, &where{field: field, condition: condition, value: value})
 	return nil
 }
 
+// OrWhereCondition where condition joined by OR
+func (b *Builder) OrWhereCondition(field string, condition string, value interface{}) error {
+	b.wheres = append(b.wheres, &where{or: true, field: field, condition: condition, value: value})
+	return nil
+}
+
+// WhereRaw the raw condition in parentheses, joined by AND
+func (b *Builder) WhereRaw(sql string, bindings ...interface{}) {
+	b.wheres = append(b.wheres, &where{raw: true, value: Expr(sql, bindings...)})
+}
+
+// OrWhereRaw the raw condition in parentheses, joined by OR
+func (b *Builder) OrWhereRaw(sql string, bindings ...interface{}) {
+	b.wheres = append(b.wheres, &where{or: true, raw: true, value: Expr(sql, bindings...)})
+}
+
+// WhereExists EXISTS the subquery, joined by AND
+func (b *Builder) WhereExists(sub *SubQuery) {
+	b.wheres = append(b.wheres, &where{condition: "EXISTS", value: sub})
+}
+
+// WhereNotExists NOT EXISTS the subquery, joined by AND
+func (b *Builder) WhereNotExists(sub *SubQuery) {
+	b.wheres = append(b.wheres, &where{condition: "NOT EXISTS", value: sub})
+}
+
+// OrWhereExists EXISTS the subquery, joined by OR
+func (b *Builder) OrWhereExists(sub *SubQuery) {
+	b.wheres = append(b.wheres, &where{or: true, condition: "EXISTS", value: sub})
+}
+
+// WhereGroup the conditions of the closure in parentheses, joined by AND, an empty group is ignored
+func (b *Builder) WhereGroup(closure func(q *Builder)) {
+	b.whereGroup(false, closure)
+}
+
+// OrWhereGroup the conditions of the closure in parentheses, joined by OR, an empty group is ignored
+func (b *Builder) OrWhereGroup(closure func(q *Builder)) {
+	b.whereGroup(true, closure)
+}
+
+func (b *Builder) whereGroup(or bool, closure func(q *Builder)) {
+	q := NewBuilder()
+	q.model = b.model
+	closure(q)
+	if len(q.wheres) == 0 {
+		return
+	}
+	b.wheres = append(b.wheres, &where{or: or, group: q})
+}
+
+// GroupBy group by the fields
+func (b *Builder) GroupBy(fields ...string) {
+	b.groups = append(b.groups, fields...)
+}
+
+// HavingCondition having condition
+func (b *Builder) HavingCondition(field string, condition string, value interface{}) {
+	b.havings = append(b.havings, &where{field: field, condition: condition, value: value})
+}
+
+// OrHavingCondition having condition joined by OR
+func (b *Builder) OrHavingCondition(field string, condition string, value interface{}) {
+	b.havings = append(b.havings, &where{or: true, field: field, condition: condition, value: value})
+}
+
+// HavingRaw the raw having condition in parentheses, eg: HavingRaw("count(*) > ?", 1)
+func (b *Builder) HavingRaw(sql string, bindings ...interface{}) {
+	b.havings = append(b.havings, &where{raw: true, value: Expr(sql, bindings...)})
+}
+
+// Aggregate select the aggregate function of the field instead of count(*) in the OPCount
+func (b *Builder) Aggregate(function string, field string) {
+	b.aggregate = []string{function, field}
+}
+
 // OrderBy ASC sort
 func (b *Builder) OrderBy(field string) {
 	b.orders = append(b.orders, []string{"ASC", field})
@@ -58,6 +251,42 @@ func (b *Builder) OrderByDesc(field string) {
 	b.orders = append(b.orders, []string{"DESC", field})
 }
 
+// OrderByRaw sort by the raw expression
+func (b *Builder) OrderByRaw(sql string, bindings ...interface{}) {
+	b.orders = append(b.orders, []string{"", sql})
+	b.orderRaws = append(b.orderRaws, Expr(sql, bindings...))
+}
+
+// Set set the field to the value when updating, the value can be an Expression
+func (b *Builder) Set(field string, value interface{}) {
+	b.sets = append(b.sets, []interface{}{field, value})
+}
+
+// ForUpdate lock the selected rows for update
+func (b *Builder) ForUpdate() {
+	b.lock = "UPDATE"
+}
+
+// ForShare lock the selected rows in share mode
+func (b *Builder) ForShare() {
+	b.lock = "SHARE"
+}
+
+// SkipLocked skip the locked rows, FOR UPDATE by default
+func (b *Builder) SkipLocked() {
+	b.lockOption = "SKIP LOCKED"
+}
+
+// NoWait fail instead of waiting for the locked rows, FOR UPDATE by default
+func (b *Builder) NoWait() {
+	b.lockOption = "NOWAIT"
+}
+
+// locked whether the rows are locked
+func (b *Builder) locked() bool {
+	return b.lock != "" || b.lockOption != ""
+}
+
 // Update update opreate, pass the fields that need to be updated
 func (b *Builder) Update(updateFields []string) {
 	b.updateFields = updateFields
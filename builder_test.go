@@ -49,3 +49,18 @@ func TestUpdate(t *testing.T) {
 	b.Update([]string{"age"})
 	assert.Equal(t, []string{"age"}, b.updateFields)
 }
+
+func TestWhereGroup(t *testing.T) {
+	builder := NewBuilder()
+	builder.WhereGroup(func(q *Builder) {
+		q.WhereCondition("status", "=", 1)
+		q.OrWhereCondition("status", "=", 2)
+	})
+	builder.OrWhereGroup(func(q *Builder) {})
+	builder.OrWhereCondition("age", ">", 18)
+	assert.Equal(t, 2, len(builder.wheres))
+	assert.False(t, builder.wheres[0].or)
+	assert.Equal(t, 2, len(builder.wheres[0].group.wheres))
+	assert.True(t, builder.wheres[0].group.wheres[1].or)
+	assert.True(t, builder.wheres[1].or)
+}
@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"errors"
 	"reflect"
+	"strings"
 	"time"
 )
 
@@ -16,6 +17,8 @@ type (
 		paginateTotal int64
 		originModel   *Model
 		err           error
+		// release finish counting the query in progress, after the rows are closed
+		release func()
 	}
 )
 
@@ -43,11 +46,24 @@ func (c *Collect) Next() bool {
 	if c.sqlRows.Next() {
 		return fn()
 	}
-	//no data
-	c.sqlRows.Close()
+	//no data, or interrupted, eg: the context is done
+	if c.err == nil {
+		c.err = c.sqlRows.Err()
+	}
+	c.Close()
 	return false
 }
 
+// Close close the rows, the rows are closed when Next returns false,
+// call it when the iteration stops before
+func (c *Collect) Close() error {
+	err := c.sqlRows.Close()
+	if c.release != nil {
+		c.release()
+	}
+	return err
+}
+
 // Total get the total number of queries, just Model.Paginate
 func (c *Collect) Total() int64 {
 	return c.paginateTotal
@@ -83,7 +99,7 @@ func (c *Collect) setCurrentEntity() error {
 
 	//assign
 	for idx, cName := range columns {
-		if field, ok := c.originModel.entityFields[cName]; ok {
+		if field, ok := c.entityField(cName); ok {
 			fValue := rValue.Elem().FieldByName(field.sName)
 			if fValue.CanSet() {
 				switch field.fType {
@@ -98,19 +114,8 @@ func (c *Collect) setCurrentEntity() error {
 				case "float32", "float64":
 					fValue.SetFloat(*values[idx].(*float64))
 				case "Time", "time.Time":
-					switch field.fTagType {
-					case TagTime:
-						if t, err := time.ParseInLocation(FTimeTime, *values[idx].(*string), time.Local); err == nil {
-							fValue.Set(reflect.ValueOf(t))
-						}
-					case TagDate:
-						if t, err := time.ParseInLocation(FTimeDate, *values[idx].(*string), time.Local); err == nil {
-							fValue.Set(reflect.ValueOf(t))
-						}
-					case TagDateTime:
-						if t, err := time.ParseInLocation(FTimeDateTime, *values[idx].(*string), time.Local); err == nil {
-							fValue.Set(reflect.ValueOf(t))
-						}
+					if t, ok := parseTime(field.fTagType, *values[idx].(*interface{})); ok {
+						fValue.Set(reflect.ValueOf(t))
 					}
 				default:
 				}
@@ -124,7 +129,7 @@ func (c *Collect) setCurrentEntity() error {
 
 func (c *Collect) initScanValues(columns []string, values []interface{}) {
 	for idx, cName := range columns {
-		field, ok := c.originModel.entityFields[cName]
+		field, ok := c.entityField(cName)
 		if !ok {
 			values[idx] = new(interface{})
 		} else {
@@ -140,10 +145,60 @@ func (c *Collect) initScanValues(columns []string, values []interface{}) {
 			case "float32", "float64":
 				values[idx] = new(float64)
 			case "Time", "time.Time":
-				values[idx] = new(string)
+				//string or []byte, or time.Time from the drivers that parse it, nil if NULL
+				values[idx] = new(interface{})
 			default:
 				values[idx] = new(interface{})
 			}
 		}
 	}
 }
+
+// entityField the entity field of the column, the table__column alias of a join maps to
+// the column of the entity table, or to the field table_column of a flat result struct
+func (c *Collect) entityField(cName string) (Field, bool) {
+	fields := c.originModel.entityFields
+	if field, ok := fields[cName]; ok {
+		return field, true
+	}
+	i := strings.LastIndex(cName, "__")
+	if i <= 0 {
+		return Field{}, false
+	}
+	if cName[:i] == c.originModel.tableName {
+		if field, ok := fields[cName[i+2:]]; ok {
+			return field, true
+		}
+	}
+	field, ok := fields[strings.ReplaceAll(cName, "__", "_")]
+	return field, ok
+}
+
+// parseTime parse the scanned value of a time field by the tag
+func parseTime(tagType string, value interface{}) (time.Time, bool) {
+	var str string
+	switch v := value.(type) {
+	case time.Time:
+		return v, tagType != ""
+	case []byte:
+		str = string(v)
+	case string:
+		str = v
+	default:
+		return time.Time{}, false
+	}
+
+	layout := ""
+	switch tagType {
+	case TagTime:
+		layout = FTimeTime
+	case TagDate:
+		layout = FTimeDate
+	case TagDateTime:
+		layout = FTimeDateTime
+	default:
+		return time.Time{}, false
+	}
+	t, err := time.ParseInLocation(layout, str, time.Local)
+	return t, err == nil
+}
@@ -6,14 +6,14 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	//_ test
-	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/mattn/go-sqlite3"
 )
 
 // In addition to regular operations like model => model_test.go file
 // you can alse customize structure mapping
 // for test, just QueryCollect
 func TestMappingStructure(t *testing.T) {
-	TestBoot(t)
+	testBoot(t)
 
 	type UserCount struct {
 		UserName string
@@ -23,7 +23,7 @@ func TestMappingStructure(t *testing.T) {
 	m, err := New(&UserCount{})
 	assert.Nil(t, err)
 
-	m.Exec("truncate `user`;")
+	m.Exec("DELETE FROM `user`;")
 
 	//insert data
 	// name = 'tom' => 8 records
@@ -75,3 +75,26 @@ func testInsertDataForMappingStructure(t *testing.T) {
 		m.Insert()
 	}
 }
+
+func TestParseTime(t *testing.T) {
+	dt := time.Date(2021, 8, 9, 16, 22, 22, 0, time.Local)
+
+	v, ok := parseTime(TagDateTime, []byte("2021-08-09 16:22:22"))
+	assert.True(t, ok)
+	assert.Equal(t, dt, v)
+
+	v, ok = parseTime(TagDate, "2021-08-09")
+	assert.True(t, ok)
+	assert.Equal(t, time.Date(2021, 8, 9, 0, 0, 0, 0, time.Local), v)
+
+	//drivers that parse the time
+	v, ok = parseTime(TagDateTime, dt)
+	assert.True(t, ok)
+	assert.Equal(t, dt, v)
+
+	//NULL
+	_, ok = parseTime(TagDateTime, nil)
+	assert.False(t, ok)
+	_, ok = parseTime(TagTime, "bad")
+	assert.False(t, ok)
+}
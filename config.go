@@ -1,6 +1,8 @@
 package edb
 
-import "fmt"
+import (
+	"time"
+)
 
 type (
 
@@ -14,17 +16,114 @@ type (
 		Password  string
 		Charset   string
 		Collation string
+		// Socket unix socket path, used instead of Host and Port
+		Socket string
+		// TLS tls param of the driver, eg: true, skip-verify, preferred or a registered config name
+		TLS string
+		// Timeout dial timeout
+		Timeout      time.Duration
+		ReadTimeout  time.Duration
+		WriteTimeout time.Duration
+		// ParseTime parseTime param of the driver
+		ParseTime bool
+		// Loc location param of the driver, eg: Local, UTC, Asia/Shanghai
+		Loc string
+		// Params extra driver params
+		Params map[string]string
+		// Replicas read replicas, reads are load-balanced across them,
+		// empty fields are inherited from the primary config
+		Replicas []*Config
+		// Sticky after a write with the context of StickyContext, the reads with the context go to the primary
+		Sticky bool
+		// Lazy do not connect until the first statement
+		Lazy bool
+		// RetryTimeout keep retrying to connect until the timeout, 0 tries once
+		RetryTimeout time.Duration
+		// RetryInterval the first retry interval, doubled after each retry, default 100ms
+		RetryInterval time.Duration
+		// MaxOpenConns sql.DB.SetMaxOpenConns, 0 keeps the default
+		MaxOpenConns int
+		// MaxIdleConns sql.DB.SetMaxIdleConns, 0 keeps the default
+		MaxIdleConns int
+		// ConnMaxLifetime sql.DB.SetConnMaxLifetime, 0 keeps the default
+		ConnMaxLifetime time.Duration
+		// ConnMaxIdleTime sql.DB.SetConnMaxIdleTime, 0 keeps the default
+		ConnMaxIdleTime time.Duration
+		// HealthCheckInterval ping the pool on the interval in the background, 0 disables it,
+		// unhealthy replicas are skipped by reads
+		HealthCheckInterval time.Duration
+
+		// memoryName the name of the sqlite :memory: database of the config
+		memoryName string
 	}
 )
 
-// DriverMysql driver support
-const DriverMysql = "mysql"
+// driver support
+const (
+	DriverMysql    = "mysql"
+	DriverPostgres = "postgres"
+	// DriverSqlite Config.Database is the file path or :memory:
+	DriverSqlite = "sqlite3"
+	// DriverSqlserver the go-mssqldb url driver name
+	DriverSqlserver = "sqlserver"
+)
 
-// DNS return dns string
+// DNS return dns string, built by the dialect of the driver
 func (c *Config) DNS() string {
-	switch c.Driver {
-	case DriverMysql:
-		return fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=%s", c.Username, c.Password, c.Host, c.Port, c.Database, c.Charset)
+	if d, ok := LookupDialect(c.Driver); ok {
+		return d.DSN(c)
 	}
 	return ""
 }
+
+// merge fill the empty fields of r from c, eg: the replica config from the primary config
+func (c *Config) merge(r *Config) *Config {
+	rc := *r
+	rc.Replicas = nil
+	fill := func(dst *string, src string) {
+		if *dst == "" {
+			*dst = src
+		}
+	}
+	fill(&rc.Driver, c.Driver)
+	fill(&rc.Host, c.Host)
+	fill(&rc.Port, c.Port)
+	fill(&rc.Database, c.Database)
+	fill(&rc.Username, c.Username)
+	fill(&rc.Password, c.Password)
+	fill(&rc.Charset, c.Charset)
+	fill(&rc.Collation, c.Collation)
+	fill(&rc.Socket, c.Socket)
+	fill(&rc.TLS, c.TLS)
+	fill(&rc.Loc, c.Loc)
+	if !rc.ParseTime {
+		rc.ParseTime = c.ParseTime
+	}
+	if rc.Params == nil {
+		rc.Params = c.Params
+	}
+	if !rc.Lazy {
+		rc.Lazy = c.Lazy
+	}
+	fillInt := func(dst *int, src int) {
+		if *dst == 0 {
+			*dst = src
+		}
+	}
+	fillInt(&rc.MaxOpenConns, c.MaxOpenConns)
+	fillInt(&rc.MaxIdleConns, c.MaxIdleConns)
+	fillDuration := func(dst *time.Duration, src time.Duration) {
+		if *dst == 0 {
+			*dst = src
+		}
+	}
+	fillDuration(&rc.Timeout, c.Timeout)
+	fillDuration(&rc.ReadTimeout, c.ReadTimeout)
+	fillDuration(&rc.WriteTimeout, c.WriteTimeout)
+	fillDuration(&rc.RetryTimeout, c.RetryTimeout)
+	fillDuration(&rc.RetryInterval, c.RetryInterval)
+	fillDuration(&rc.ConnMaxLifetime, c.ConnMaxLifetime)
+	fillDuration(&rc.ConnMaxIdleTime, c.ConnMaxIdleTime)
+	fillDuration(&rc.HealthCheckInterval, c.HealthCheckInterval)
+	return &rc
+}
@@ -30,3 +30,13 @@ func TestDns(t *testing.T) {
 		}
 	}
 }
+
+func TestConfigReplica(t *testing.T) {
+	c := &Config{Driver: "mysql", Host: "127.0.0.1", Port: "3306", Username: "root", Password: "123", Database: "test", Charset: "utf8"}
+	r := c.merge(&Config{Host: "10.0.0.2", Username: "reader"})
+
+	expect := "reader:123@tcp(10.0.0.2:3306)/test?charset=utf8"
+	if r.DNS() != expect {
+		t.Errorf("expect: %s, actually: %s", expect, r.DNS())
+	}
+}
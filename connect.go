@@ -1,23 +1,81 @@
 package edb
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrClosed the connections have been closed by Close or Shutdown
+var ErrClosed = errors.New("edb: connect is closed")
+
+const (
+	defaultRetryInterval = 100 * time.Millisecond
+	maxRetryInterval     = 5 * time.Second
 )
 
 type (
 
 	// connect connections
 	connect struct {
-		db     *sql.DB
+		mu     sync.RWMutex
+		pools  map[string]*pool
 		config map[string]*Config
-		driver string
+		// current the default connection name, the first connected one
+		current string
+		closed  bool
+		// inflight statements and transactions in progress
+		inflight sync.WaitGroup
+	}
+
+	// pool the connection pool of a connect name
+	pool struct {
+		conn     *connect
+		config   *Config
+		db       *sql.DB
+		replicas []*pool
+		sticky   bool
+		// next round-robin counter of the replicas
+		next uint32
+		// ready has pinged successfully, lazy pools ping on first use
+		ready   uint32
+		readyMu sync.Mutex
+		// healthy result of the last health check
+		healthMu  sync.RWMutex
+		healthy   bool
+		healthErr error
+		stop      chan struct{}
+		stopOnce  sync.Once
+	}
+
+	// stickyScope the sticky pools written with the context of StickyContext
+	stickyScope struct {
+		mu      sync.Mutex
+		written map[*pool]bool
+	}
+
+	// stickyKey the context key of the sticky scope
+	stickyKey struct{}
+
+	// PoolStats statistics and health of a connection pool
+	PoolStats struct {
+		sql.DBStats
+		// Healthy the last health check succeeded, always true without Config.HealthCheckInterval
+		Healthy bool
+		// Err the error of the last health check
+		Err      error
+		Replicas []*PoolStats
 	}
 )
 
 // newConnect new
 func newConnect() *connect {
 	return &connect{
+		pools:  make(map[string]*pool, 10),
 		config: make(map[string]*Config, 10),
 	}
 }
@@ -27,57 +85,425 @@ func (conn *connect) AddConfig(connectName string, config *Config) bool {
 	if config.DNS() == "" {
 		return false
 	}
+	conn.mu.Lock()
+	defer conn.mu.Unlock()
 	conn.config[connectName] = config
 	return true
 }
 
-// Connect connect to the database
+// Connect connect to the database, the first connected one becomes the default connection.
+// Ping is retried with backoff until Config.RetryTimeout, a Config.Lazy connection
+// is not pinged until its first use
 func (conn *connect) Connect(connectName string) error {
 
-	var (
-		c  *Config
-		ok bool
-	)
-
-	if c, ok = conn.config[connectName]; !ok {
+	conn.mu.RLock()
+	c, ok := conn.config[connectName]
+	closed := conn.closed
+	conn.mu.RUnlock()
+	if closed {
+		return ErrClosed
+	}
+	if !ok {
 		return fmt.Errorf("edb: connect.Connect err: %s : database connection configuration dose not exist", connectName)
 	}
 
-	conn.driver = c.Driver
-
-	db, err := sql.Open(c.Driver, c.DNS())
+	p, err := conn.openPool(c)
 	if err != nil {
 		return fmt.Errorf("edb: connect.Connect err: %s", err.Error())
 	}
+	p.sticky = c.Sticky
+	for _, r := range c.Replicas {
+		rc := c.merge(r)
+		rp, err := conn.openPool(rc)
+		if err != nil {
+			p.close()
+			return fmt.Errorf("edb: connect.Connect err: replica %s:%s : %s", rc.Host, rc.Port, err.Error())
+		}
+		p.replicas = append(p.replicas, rp)
+	}
 
-	err2 := db.Ping()
-	if err2 != nil {
-		return fmt.Errorf("edb: connect.Connect err: %s", err2.Error())
+	conn.mu.Lock()
+	old := conn.pools[connectName]
+	conn.pools[connectName] = p
+	if conn.current == "" {
+		conn.current = connectName
 	}
-	conn.db = db
+	conn.mu.Unlock()
 
+	if old != nil {
+		old.close()
+	}
 	return nil
 }
 
-// Db sql.DB
+// openPool open the database, and ping it unless the config is lazy
+func (conn *connect) openPool(c *Config) (*pool, error) {
+	db, err := sql.Open(c.Driver, c.DNS())
+	if err != nil {
+		return nil, err
+	}
+
+	if c.MaxOpenConns > 0 {
+		db.SetMaxOpenConns(c.MaxOpenConns)
+	}
+	if c.MaxIdleConns > 0 {
+		db.SetMaxIdleConns(c.MaxIdleConns)
+	}
+	if c.ConnMaxLifetime > 0 {
+		db.SetConnMaxLifetime(c.ConnMaxLifetime)
+	}
+	if c.ConnMaxIdleTime > 0 {
+		db.SetConnMaxIdleTime(c.ConnMaxIdleTime)
+	}
+
+	p := &pool{
+		conn:    conn,
+		config:  c,
+		db:      db,
+		healthy: true,
+		stop:    make(chan struct{}),
+	}
+	if !c.Lazy {
+		if err := p.ensure(context.Background()); err != nil {
+			db.Close()
+			return nil, err
+		}
+	}
+	if c.HealthCheckInterval > 0 {
+		go p.healthCheck(c.HealthCheckInterval)
+	}
+	return p, nil
+}
+
+// pool get the pool of connectName, "" is the default connection
+func (conn *connect) pool(connectName string) (*pool, error) {
+	conn.mu.RLock()
+	defer conn.mu.RUnlock()
+
+	if connectName == "" {
+		connectName = conn.current
+	}
+	p, ok := conn.pools[connectName]
+	if !ok {
+		return nil, fmt.Errorf("edb: connect.pool err: %s : database is not connected", connectName)
+	}
+	return p, nil
+}
+
+// driver get the driver of connectName, "" is the default connection
+func (conn *connect) driver(connectName string) string {
+	conn.mu.RLock()
+	defer conn.mu.RUnlock()
+
+	if connectName == "" {
+		connectName = conn.current
+	}
+	if c, ok := conn.config[connectName]; ok {
+		return c.Driver
+	}
+	return ""
+}
+
+// Db sql.DB of the default connection
 func (conn *connect) Db() *sql.DB {
-	return conn.db
+	p, err := conn.pool("")
+	if err != nil {
+		return nil
+	}
+	return p.db
 }
 
-// Exec DB.Exec
+// Exec DB.Exec on the default connection
 func (conn *connect) Exec(query string, args ...interface{}) (sql.Result, error) {
-	return conn.db.Exec(query, args...)
+	p, err := conn.pool("")
+	if err != nil {
+		return nil, err
+	}
+	return p.ExecContext(context.Background(), query, args...)
 }
 
-// Query query
+// Query query on the default connection, load-balanced across the replicas
 func (conn *connect) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	p, err := conn.pool("")
+	if err != nil {
+		return nil, err
+	}
+	return p.reader().QueryContext(context.Background(), query, args...)
+}
 
-	stmt, err := conn.db.Prepare(query)
-	defer stmt.Close()
+// Stats statistics and health of the pool of connectName, "" is the default connection
+func (conn *connect) Stats(connectName string) (*PoolStats, error) {
+	p, err := conn.pool(connectName)
+	if err != nil {
+		return nil, err
+	}
+	return p.stats(), nil
+}
+
+// Close refuse new statements and close every pool
+func (conn *connect) Close() error {
+	conn.mu.Lock()
+	defer conn.mu.Unlock()
+	conn.closed = true
+
+	var err error
+	for _, p := range conn.pools {
+		if err2 := p.close(); err2 != nil && err == nil {
+			err = err2
+		}
+	}
+	return err
+}
+
+// Shutdown refuse new statements, wait for the statements and transactions
+// in progress until ctx is done, then close every pool
+func (conn *connect) Shutdown(ctx context.Context) error {
+	conn.mu.Lock()
+	conn.closed = true
+	conn.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		conn.inflight.Wait()
+		close(done)
+	}()
+
+	var err error
+	select {
+	case <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	if err2 := conn.Close(); err == nil {
+		err = err2
+	}
+	return err
+}
 
+// acquire count a statement or transaction in progress, call release when it finishes
+func (conn *connect) acquire() error {
+	conn.mu.RLock()
+	defer conn.mu.RUnlock()
+
+	if conn.closed {
+		return ErrClosed
+	}
+	conn.inflight.Add(1)
+	return nil
+}
+
+// release release what acquire counted
+func (conn *connect) release() {
+	conn.inflight.Done()
+}
+
+// reader the pool to read from, round-robin across the healthy replicas,
+// the primary itself if there is no healthy replica
+func (p *pool) reader() *pool {
+	l := len(p.replicas)
+	for i := 0; i < l; i++ {
+		n := atomic.AddUint32(&p.next, 1)
+		if r := p.replicas[int(n-1)%l]; r.isHealthy() {
+			return r
+		}
+	}
+	return p
+}
+
+// StickyContext return a copy of ctx scoping Config.Sticky, usually one per request,
+// after a write on a sticky connection with the context, the reads with the context go to the primary
+//
+// Example usage:
+//
+// (
+// 	ctx := edb.StickyContext(r.Context())
+// 	m.WithContext(ctx).Insert()
+// 	//read from the primary
+// 	e, err := m2.WithContext(ctx).Eq("name", "tom").First()
+// )
+func StickyContext(ctx context.Context) context.Context {
+	return context.WithValue(ctx, stickyKey{}, &stickyScope{written: make(map[*pool]bool)})
+}
+
+// wrote record the write on a sticky pool in the sticky scope of ctx
+func (p *pool) wrote(ctx context.Context) {
+	if !p.sticky {
+		return
+	}
+	if s, ok := ctx.Value(stickyKey{}).(*stickyScope); ok {
+		s.mu.Lock()
+		s.written[p] = true
+		s.mu.Unlock()
+	}
+}
+
+// readerContext the primary if it has been written in the sticky scope of ctx, otherwise reader
+func (p *pool) readerContext(ctx context.Context) *pool {
+	if s, ok := ctx.Value(stickyKey{}).(*stickyScope); ok {
+		s.mu.Lock()
+		written := s.written[p]
+		s.mu.Unlock()
+		if written {
+			return p
+		}
+	}
+	return p.reader()
+}
+
+// healthCheck ping the database on the interval until the pool is closed
+func (p *pool) healthCheck(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), interval)
+			err := p.db.PingContext(ctx)
+			cancel()
+
+			p.healthMu.Lock()
+			p.healthy = err == nil
+			p.healthErr = err
+			p.healthMu.Unlock()
+		}
+	}
+}
+
+// isHealthy the last health check succeeded
+func (p *pool) isHealthy() bool {
+	p.healthMu.RLock()
+	defer p.healthMu.RUnlock()
+	return p.healthy
+}
+
+// stats statistics and health of the pool and its replicas
+func (p *pool) stats() *PoolStats {
+	p.healthMu.RLock()
+	s := &PoolStats{
+		DBStats: p.db.Stats(),
+		Healthy: p.healthy,
+		Err:     p.healthErr,
+	}
+	p.healthMu.RUnlock()
+	for _, r := range p.replicas {
+		s.Replicas = append(s.Replicas, r.stats())
+	}
+	return s
+}
+
+// ensure ping the database once, retry with backoff until Config.RetryTimeout
+func (p *pool) ensure(ctx context.Context) error {
+	if atomic.LoadUint32(&p.ready) == 1 {
+		return nil
+	}
+	p.readyMu.Lock()
+	defer p.readyMu.Unlock()
+	if atomic.LoadUint32(&p.ready) == 1 {
+		return nil
+	}
+
+	interval := p.config.RetryInterval
+	if interval <= 0 {
+		interval = defaultRetryInterval
+	}
+	deadline := time.Now().Add(p.config.RetryTimeout)
+	for {
+		err := p.db.PingContext(ctx)
+		if err == nil {
+			atomic.StoreUint32(&p.ready, 1)
+			return nil
+		}
+		if time.Now().Add(interval).After(deadline) {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(interval):
+		}
+		if interval *= 2; interval > maxRetryInterval {
+			interval = maxRetryInterval
+		}
+	}
+}
+
+// acquire connect lazily and count the statement in progress
+func (p *pool) acquire(ctx context.Context) error {
+	if err := p.conn.acquire(); err != nil {
+		return err
+	}
+	if err := p.ensure(ctx); err != nil {
+		p.conn.release()
+		return err
+	}
+	return nil
+}
+
+// close stop the health check, close the primary and the replicas
+func (p *pool) close() error {
+	p.stopOnce.Do(func() {
+		close(p.stop)
+	})
+	for _, r := range p.replicas {
+		r.close()
+	}
+	return p.db.Close()
+}
+
+// BeginTx starts a transaction, counted in progress until the transaction is released
+func (p *pool) BeginTx(ctx context.Context) (*sql.Tx, error) {
+	if err := p.acquire(ctx); err != nil {
+		return nil, err
+	}
+	sqlTx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		p.conn.release()
+		return nil, err
+	}
+	return sqlTx, nil
+}
+
+// ExecContext DB.ExecContext, driver errors are classified by the dialect
+func (p *pool) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	if err := p.acquire(ctx); err != nil {
+		return nil, err
+	}
+	defer p.conn.release()
+
+	sqlResult, err := p.db.ExecContext(ctx, query, args...)
+	return sqlResult, classifyError(p.config.Driver, err)
+}
+
+// QueryContext query, counted in progress until the rows are returned, see queryContext
+func (p *pool) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	sqlRows, release, err := p.queryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
+	release()
+	return sqlRows, nil
+}
+
+// queryContext query, counted in progress until release is called after the rows are closed
+func (p *pool) queryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, func(), error) {
+	if err := p.acquire(ctx); err != nil {
+		return nil, nil, err
+	}
+
+	stmt, err := p.db.PrepareContext(ctx, query)
+	if err != nil {
+		p.conn.release()
+		return nil, nil, classifyError(p.config.Driver, err)
+	}
+	defer stmt.Close()
 
-	return stmt.Query(args...)
+	sqlRows, err := stmt.QueryContext(ctx, args...)
+	if err != nil {
+		p.conn.release()
+		return nil, nil, classifyError(p.config.Driver, err)
+	}
+	var once sync.Once
+	return sqlRows, func() { once.Do(p.conn.release) }, nil
 }
@@ -1,7 +1,9 @@
 package edb
 
 import (
+	"context"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	//_ test
@@ -18,12 +20,15 @@ func TestConnect(t *testing.T) {
 	err := c.Connect("test")
 	assert.Equal(t, "edb: connect.Connect err: test : database connection configuration dose not exist", err.Error())
 
+	skipWithoutMysql(t)
 	c.AddConfig("test", &Config{Driver: "mysql", Host: "127.0.0.1", Port: "3306", Username: "root", Password: "12345678", Database: "test", Charset: "utf8"})
 	err = c.Connect("test")
 	assert.NoError(t, err)
 }
 
 func TestExec(t *testing.T) {
+	skipWithoutMysql(t)
+
 	c := newConnect()
 	c.AddConfig("test", &Config{Driver: "mysql", Host: "127.0.0.1", Port: "3306", Username: "root", Password: "12345678", Database: "test", Charset: "utf8"})
 	err := c.Connect("test")
@@ -56,6 +61,8 @@ func TestExec(t *testing.T) {
 }
 
 func TestQuery(t *testing.T) {
+	skipWithoutMysql(t)
+
 	c := newConnect()
 	c.AddConfig("test", &Config{Driver: "mysql", Host: "127.0.0.1", Port: "3306", Username: "root", Password: "12345678", Database: "test", Charset: "utf8"})
 	err := c.Connect("test")
@@ -81,3 +88,157 @@ func TestQuery(t *testing.T) {
 
 	}
 }
+
+func TestConnectMultiple(t *testing.T) {
+	c := newConnect()
+	_, err := c.pool("")
+	assert.EqualError(t, err, "edb: connect.pool err:  : database is not connected")
+
+	skipWithoutMysql(t)
+	c.AddConfig("test", &Config{Driver: "mysql", Host: "127.0.0.1", Port: "3306", Username: "root", Password: "12345678", Database: "test", Charset: "utf8"})
+	c.AddConfig("reporting", &Config{Driver: "mysql", Host: "127.0.0.1", Port: "3306", Username: "root", Password: "12345678", Database: "test", Charset: "utf8"})
+	assert.NoError(t, c.Connect("test"))
+	assert.NoError(t, c.Connect("reporting"))
+
+	//the first connected one is the default
+	assert.Equal(t, "test", c.current)
+	p, err := c.pool("")
+	assert.NoError(t, err)
+	p2, err := c.pool("reporting")
+	assert.NoError(t, err)
+	assert.NotEqual(t, p.db, p2.db)
+
+	_, err = c.pool("other")
+	assert.EqualError(t, err, "edb: connect.pool err: other : database is not connected")
+}
+
+func TestPoolReader(t *testing.T) {
+	p := &pool{}
+	assert.Equal(t, p, p.reader())
+
+	r1, r2 := &pool{healthy: true}, &pool{healthy: true}
+	p.replicas = []*pool{r1, r2}
+	assert.Same(t, r1, p.reader())
+	assert.Same(t, r2, p.reader())
+	assert.Same(t, r1, p.reader())
+
+	//skip the unhealthy replicas
+	r1.healthy = false
+	assert.Same(t, r2, p.reader())
+	assert.Same(t, r2, p.reader())
+	r2.healthy = false
+	assert.Same(t, p, p.reader())
+}
+
+func TestConnectReplicas(t *testing.T) {
+	skipWithoutMysql(t)
+
+	c := newConnect()
+	c.AddConfig("test", &Config{
+		Driver: "mysql", Host: "127.0.0.1", Port: "3306", Username: "root", Password: "12345678", Database: "test", Charset: "utf8",
+		Replicas: []*Config{{Host: "127.0.0.1"}, {Host: "localhost"}},
+	})
+	assert.NoError(t, c.Connect("test"))
+	p, err := c.pool("test")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(p.replicas))
+
+	c.AddConfig("test2", &Config{
+		Driver: "mysql", Host: "127.0.0.1", Port: "3306", Username: "root", Password: "12345678", Database: "test", Charset: "utf8",
+		Replicas: []*Config{{Host: "127.0.0.1", Password: "123"}},
+	})
+	assert.EqualError(t, c.Connect("test2"), "edb: connect.Connect err: replica 127.0.0.1:3306 : Error 1045: Access denied for user 'root'@'localhost' (using password: YES)")
+}
+
+func TestConnectRetry(t *testing.T) {
+	c := newConnect()
+	c.AddConfig("test", &Config{Driver: "mysql", Host: "127.0.0.1", Port: "1", Username: "root", Password: "12345678", Database: "test", Charset: "utf8",
+		RetryTimeout: time.Millisecond * 300, RetryInterval: time.Millisecond * 50})
+
+	start := time.Now()
+	err := c.Connect("test")
+	assert.Error(t, err)
+	//50 + 100 ms retried before the next interval exceeds the deadline
+	assert.True(t, time.Since(start) >= time.Millisecond*150)
+	_, err = c.pool("test")
+	assert.Error(t, err)
+}
+
+func TestConnectLazy(t *testing.T) {
+	c := newConnect()
+	c.AddConfig("test", &Config{Driver: "mysql", Host: "127.0.0.1", Port: "1", Username: "root", Password: "12345678", Database: "test", Charset: "utf8", Lazy: true})
+
+	//not connected until the first statement
+	assert.NoError(t, c.Connect("test"))
+	p, err := c.pool("test")
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(0), p.ready)
+
+	_, err = c.Exec("SELECT 1")
+	assert.Error(t, err)
+	assert.Equal(t, uint32(0), p.ready)
+}
+
+func TestConnectClose(t *testing.T) {
+	c := newConnect()
+	c.AddConfig("test", &Config{Driver: "mysql", Host: "127.0.0.1", Port: "1", Username: "root", Password: "12345678", Database: "test", Charset: "utf8", Lazy: true})
+	assert.NoError(t, c.Connect("test"))
+
+	assert.NoError(t, c.Close())
+	_, err := c.Exec("SELECT 1")
+	assert.Equal(t, ErrClosed, err)
+	_, err = c.Query("SELECT 1")
+	assert.Equal(t, ErrClosed, err)
+	assert.Equal(t, ErrClosed, c.Connect("test"))
+}
+
+func TestConnectShutdown(t *testing.T) {
+	c := newConnect()
+	c.AddConfig("test", &Config{Driver: "mysql", Host: "127.0.0.1", Port: "1", Username: "root", Password: "12345678", Database: "test", Charset: "utf8", Lazy: true})
+	assert.NoError(t, c.Connect("test"))
+
+	//a statement in progress
+	assert.NoError(t, c.acquire())
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*50)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, c.Shutdown(ctx))
+	assert.Equal(t, ErrClosed, c.acquire())
+
+	c2 := newConnect()
+	c2.AddConfig("test", &Config{Driver: "mysql", Host: "127.0.0.1", Port: "1", Username: "root", Password: "12345678", Database: "test", Charset: "utf8", Lazy: true})
+	assert.NoError(t, c2.Connect("test"))
+	assert.NoError(t, c2.acquire())
+	go func() {
+		time.Sleep(time.Millisecond * 20)
+		c2.release()
+	}()
+	assert.NoError(t, c2.Shutdown(context.Background()))
+}
+
+func TestConnectStats(t *testing.T) {
+	c := newConnect()
+	c.AddConfig("test", &Config{Driver: "mysql", Host: "127.0.0.1", Port: "1", Username: "root", Password: "12345678", Database: "test", Charset: "utf8", Lazy: true,
+		MaxOpenConns: 5, HealthCheckInterval: time.Millisecond * 20,
+		Replicas: []*Config{{Host: "localhost"}},
+	})
+	assert.NoError(t, c.Connect("test"))
+	defer c.Close()
+
+	s, err := c.Stats("test")
+	assert.NoError(t, err)
+	assert.Equal(t, 5, s.MaxOpenConnections)
+	assert.True(t, s.Healthy)
+	assert.Equal(t, 1, len(s.Replicas))
+	assert.Equal(t, 5, s.Replicas[0].MaxOpenConnections)
+
+	time.Sleep(time.Millisecond * 100)
+	s2, err := c.Stats("")
+	assert.NoError(t, err)
+	assert.False(t, s2.Healthy)
+	assert.Error(t, s2.Err)
+	assert.False(t, s2.Replicas[0].Healthy)
+
+	_, err = c.Stats("other")
+	assert.EqualError(t, err, "edb: connect.pool err: other : database is not connected")
+}
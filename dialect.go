@@ -0,0 +1,242 @@
+package edb
+
+import (
+	"encoding/hex"
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// errors classified by Dialect.ClassifyError, match them by errors.Is,
+// the message is still the driver error
+var (
+	ErrDuplicateKey = errors.New("edb: duplicate key")
+	ErrForeignKey   = errors.New("edb: foreign key violation")
+	ErrNotNull      = errors.New("edb: not null violation")
+	ErrDeadlock     = errors.New("edb: deadlock")
+	ErrLockTimeout  = errors.New("edb: lock timeout")
+)
+
+const (
+	// InsertIDResult Insert returns sql.Result.LastInsertId
+	InsertIDResult InsertIDStrategy = iota
+	// InsertIDReturning Insert queries the pk returned by the Dialect.Returning clause after VALUES
+	InsertIDReturning
+	// InsertIDOutput Insert queries the pk returned by the Dialect.Returning clause before VALUES
+	InsertIDOutput
+	// InsertIDNone Insert returns 0
+	InsertIDNone
+)
+
+type (
+
+	// InsertIDStrategy how Insert gets the id of the auto increment pk
+	InsertIDStrategy int
+
+	// Dialect the sql syntax and the driver differences of a database
+	Dialect interface {
+		// Quote quote an identifier, eg: `name`
+		Quote(ident string) string
+		// Placeholder the bind placeholder of the n-th binding, n starts from 1, eg: ?, $1
+		Placeholder(n int) string
+		// Limit the clause taking limit rows after skipping offset rows, rendered after ORDER BY,
+		// eg: LIMIT 10 OFFSET 20
+		Limit(limit, offset int64) string
+		// DSN the data source name of sql.Open, "" if the config is invalid
+		DSN(c *Config) string
+		// InsertID how Insert gets the id of the auto increment pk
+		InsertID() InsertIDStrategy
+		// Returning the clause making the insert return the pk, eg: RETURNING "id",
+		// used by InsertIDReturning and InsertIDOutput
+		Returning(pk string) string
+		// ClassifyError ErrDuplicateKey, ErrForeignKey, ErrNotNull, ErrDeadlock or ErrLockTimeout
+		// of a driver error, nil if it is none of them
+		ClassifyError(err error) error
+	}
+
+	// OrderedLimiter implemented by the dialects whose Limit clause requires an ORDER BY,
+	// eg: OFFSET FETCH, a select without order is ordered by the pk
+	OrderedLimiter interface {
+		LimitRequiresOrder() bool
+	}
+
+	// Savepointer implemented by the dialects with their own savepoint sql,
+	// default SAVEPOINT, RELEASE SAVEPOINT and ROLLBACK TO SAVEPOINT,
+	// an empty ReleaseSavepoint is not executed
+	Savepointer interface {
+		Savepoint(name string) string
+		ReleaseSavepoint(name string) string
+		RollbackToSavepoint(name string) string
+	}
+
+	// Locker implemented by the dialects supporting the row locking clause rendered after the limit,
+	// share FOR SHARE instead of FOR UPDATE, option "", SKIP LOCKED or NOWAIT,
+	// the locks are rejected by the other dialects
+	Locker interface {
+		Lock(share bool, option string) string
+	}
+
+	// Wither implemented by the dialects with their own WITH keyword of the common table expressions,
+	// default WITH, WITH RECURSIVE if any is recursive
+	Wither interface {
+		With(recursive bool) string
+	}
+
+	// Literaler implemented by the dialects with their own literals of the interpolated bindings of ToRawSQL,
+	// default QuoteString doubles the quote, eg: 'it''s', Bool TRUE and FALSE, Bytes X'0aff'
+	Literaler interface {
+		QuoteString(str string) string
+		Bool(b bool) string
+		Bytes(b []byte) string
+	}
+
+	// classifiedError a driver error classified by the dialect
+	classifiedError struct {
+		kind error
+		err  error
+	}
+)
+
+var (
+	dialectsMu sync.RWMutex
+	dialects   = map[string]Dialect{
+		DriverMysql:     mysqlDialect{},
+		DriverPostgres:  postgresDialect{},
+		DriverSqlite:    sqliteDialect{},
+		DriverSqlserver: sqlserverDialect{},
+	}
+)
+
+// RegisterDialect register the dialect of the driver name, Config.Driver is the name,
+// which is also the driver name of sql.Open, a registered dialect of the name is replaced.
+// A dialect wrapping another by embedding keeps the optional interfaces of the wrapped one,
+// eg: Locker and Savepointer, unless it implements them itself
+//
+// Example usage:
+//
+// (
+//
+//	type tidb struct {
+//		edb.Dialect
+//	}
+//	mysql, _ := edb.LookupDialect("mysql")
+//	sql.Register("tidb", &mysqldriver.MySQLDriver{})
+//	edb.RegisterDialect("tidb", tidb{mysql})
+//
+// )
+func RegisterDialect(name string, d Dialect) {
+	dialectsMu.Lock()
+	defer dialectsMu.Unlock()
+	dialects[name] = d
+}
+
+// LookupDialect get the registered dialect of the driver name
+func LookupDialect(name string) (Dialect, bool) {
+	dialectsMu.RLock()
+	defer dialectsMu.RUnlock()
+	d, ok := dialects[name]
+	return d, ok
+}
+
+// dialectAs set target, a pointer to an optional interface, to the first of d and the dialects
+// it wraps by embedding that implements the interface, like errors.As
+func dialectAs(d Dialect, target interface{}) bool {
+	t := reflect.ValueOf(target).Elem()
+	for d != nil {
+		if reflect.TypeOf(d).Implements(t.Type()) {
+			t.Set(reflect.ValueOf(d))
+			return true
+		}
+		d = unwrapDialect(d)
+	}
+	return false
+}
+
+// unwrapDialect the Dialect embedded by the struct of d, nil if there is none
+func unwrapDialect(d Dialect) Dialect {
+	v := reflect.ValueOf(d)
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+	dialectType := reflect.TypeOf((*Dialect)(nil)).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		if f := v.Type().Field(i); f.Anonymous && f.Type == dialectType {
+			wrapped, _ := v.Field(i).Interface().(Dialect)
+			return wrapped
+		}
+	}
+	return nil
+}
+
+// classifyError classify err by the dialect of the driver
+func classifyError(driver string, err error) error {
+	if err == nil {
+		return nil
+	}
+	d, ok := LookupDialect(driver)
+	if !ok {
+		return err
+	}
+	if kind := d.ClassifyError(err); kind != nil {
+		return &classifiedError{kind: kind, err: err}
+	}
+	return err
+}
+
+func (e *classifiedError) Error() string {
+	return e.err.Error()
+}
+
+func (e *classifiedError) Unwrap() error {
+	return e.err
+}
+
+func (e *classifiedError) Is(target error) bool {
+	return target == e.kind
+}
+
+// limitOffset LIMIT n OFFSET m, LIMIT 1 for one row without offset
+func limitOffset(limit, offset int64) string {
+	if limit == 1 && offset == 0 {
+		return "LIMIT 1"
+	}
+	return "LIMIT " + strconv.FormatInt(limit, 10) + " OFFSET " + strconv.FormatInt(offset, 10)
+}
+
+// lockClause FOR UPDATE or FOR SHARE, followed by the option
+func lockClause(share bool, option string) string {
+	lock := "FOR UPDATE"
+	if share {
+		lock = "FOR SHARE"
+	}
+	if option != "" {
+		lock += " " + option
+	}
+	return lock
+}
+
+// quoteLiteral 'it''s', the quote is doubled
+func quoteLiteral(str string) string {
+	return "'" + strings.ReplaceAll(str, "'", "''") + "'"
+}
+
+// boolLiteral TRUE or FALSE
+func boolLiteral(b bool) string {
+	if b {
+		return "TRUE"
+	}
+	return "FALSE"
+}
+
+// hexLiteral X'0aff'
+func hexLiteral(b []byte) string {
+	return "X'" + hex.EncodeToString(b) + "'"
+}
@@ -0,0 +1,214 @@
+package edb
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type (
+	// testDialect mysql with [ident] quoting
+	testDialect struct {
+		Dialect
+	}
+
+	// testSQLStateError the error of lib/pq and pgx
+	testSQLStateError struct {
+		code string
+	}
+)
+
+func (testDialect) Quote(ident string) string {
+	return "[" + ident + "]"
+}
+
+func (e *testSQLStateError) Error() string {
+	return "duplicate key value violates unique constraint"
+}
+
+func (e *testSQLStateError) SQLState() string {
+	return e.code
+}
+
+func TestRegisterDialect(t *testing.T) {
+	_, ok := LookupDialect("edbtest")
+	assert.False(t, ok)
+	assert.Equal(t, "", (&Config{Driver: "edbtest", Host: "127.0.0.1", Database: "test"}).DNS())
+
+	mysql, ok := LookupDialect(DriverMysql)
+	assert.True(t, ok)
+	RegisterDialect("edbtest", testDialect{mysql})
+	t.Cleanup(func() {
+		dialectsMu.Lock()
+		defer dialectsMu.Unlock()
+		delete(dialects, "edbtest")
+	})
+
+	c := &Config{Driver: "edbtest", Host: "127.0.0.1", Port: "3306", Database: "test"}
+	assert.Equal(t, "tcp(127.0.0.1:3306)/test", c.DNS())
+
+	type User struct {
+		Id   int `type:"autoPk"`
+		Name string
+	}
+	mgr := NewManager()
+	assert.True(t, mgr.AddConfig("default", c))
+	m, err := mgr.Conn("default").New(&User{Id: 1})
+	assert.Nil(t, err)
+	m.stmt.SetOp(OPSelect)
+	m.builder.WhereCondition("id", "=", 1)
+	m.builder.limit = 1
+	m.builder.limitOffset = 0
+	assert.Nil(t, m.stmt.Build())
+	assert.Equal(t, "SELECT * FROM [user] WHERE [id] = ? LIMIT 1 ;", m.stmt.PrepareSQL())
+	m.reset()
+
+	m.stmt.SetOp(OPUpdate)
+	assert.EqualError(t, m.stmt.Build(), "edb Stmt.Build err: OPUpdate no updated fields")
+	m.reset()
+
+	//the optional interfaces of the wrapped mysql
+	m.stmt.SetOp(OPSelect)
+	m.ForUpdate().SkipLocked()
+	assert.Nil(t, m.stmt.Build())
+	assert.Equal(t, "SELECT * FROM [user] FOR UPDATE SKIP LOCKED ;", m.stmt.PrepareSQL())
+	m.reset()
+
+	//the backslash is escaped by the wrapped mysql
+	raw, err := m.Eq("name", `\' OR 1=1 -- `).ToRawSQL()
+	assert.Nil(t, err)
+	assert.Equal(t, `SELECT * FROM [user] WHERE [name] = '\\'' OR 1=1 -- ' ;`, raw)
+}
+
+func TestDialectAs(t *testing.T) {
+	mysql, _ := LookupDialect(DriverMysql)
+	sqlserver, _ := LookupDialect(DriverSqlserver)
+
+	var locker Locker
+	assert.True(t, dialectAs(testDialect{mysql}, &locker))
+	assert.Equal(t, mysqlDialect{}, locker)
+	assert.True(t, dialectAs(&testDialect{testDialect{mysql}}, &locker))
+	assert.Equal(t, "LOCK IN SHARE MODE", locker.Lock(true, ""))
+	assert.False(t, dialectAs(testDialect{sqliteDialect{}}, &locker))
+	assert.False(t, dialectAs(testDialect{}, &locker))
+
+	var sp Savepointer
+	assert.True(t, dialectAs(testDialect{sqlserver}, &sp))
+	assert.Equal(t, "SAVE TRANSACTION sp_1", sp.Savepoint("sp_1"))
+	var ol OrderedLimiter
+	assert.True(t, dialectAs(testDialect{sqlserver}, &ol))
+	var w Wither
+	assert.True(t, dialectAs(testDialect{sqlserver}, &w))
+	assert.False(t, dialectAs(testDialect{mysql}, &w))
+}
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		driver string
+		err    error
+		kind   error
+	}{
+		{DriverMysql, errors.New("Error 1062: Duplicate entry '1' for key 'PRIMARY'"), ErrDuplicateKey},
+		{DriverMysql, errors.New("Error 1452 (23000): Cannot add or update a child row"), ErrForeignKey},
+		{DriverMysql, errors.New("Error 1213: Deadlock found when trying to get lock"), ErrDeadlock},
+		{DriverMysql, errors.New("Error 1146: Table 'test.none' doesn't exist"), nil},
+		{DriverPostgres, &testSQLStateError{"23505"}, ErrDuplicateKey},
+		{DriverPostgres, &testSQLStateError{"55P03"}, ErrLockTimeout},
+		{DriverPostgres, errors.New("pq: syntax error"), nil},
+		{DriverSqlite, errors.New("NOT NULL constraint failed: user.name"), ErrNotNull},
+		{"unknown", errors.New("Error 1062: Duplicate entry"), nil},
+	}
+
+	for _, test := range tests {
+		err := classifyError(test.driver, test.err)
+		assert.Equal(t, test.err.Error(), err.Error())
+		assert.ErrorIs(t, err, test.err)
+		if test.kind != nil {
+			assert.ErrorIs(t, err, test.kind)
+		} else {
+			assert.Same(t, test.err, err)
+		}
+	}
+	assert.Nil(t, classifyError(DriverMysql, nil))
+}
+
+func TestModelClassifyError(t *testing.T) {
+	testBoot(t)
+
+	type User struct {
+		Id   int `type:"autoPk"`
+		Name string
+	}
+
+	m, err := New(&User{Name: "tom"})
+	assert.Nil(t, err)
+	m.Exec("DELETE FROM `user`;")
+	id, err := m.Insert()
+	assert.Nil(t, err)
+
+	m2, err := New(&User{Id: int(id), Name: "tom"})
+	assert.Nil(t, err)
+	_, err = m2.Exec("INSERT INTO `user` (`id`, `name`) VALUES (?, ?)", id, "tom")
+	assert.ErrorIs(t, err, ErrDuplicateKey)
+	assert.Contains(t, err.Error(), "UNIQUE constraint failed")
+
+	err = Transaction(func(tx *Tx) error {
+		_, err := tx.Exec("INSERT INTO `user` (`id`, `name`) VALUES (?, ?)", id, "tom")
+		return err
+	})
+	assert.ErrorIs(t, err, ErrDuplicateKey)
+}
+
+func TestDialectLock(t *testing.T) {
+	mysql := mysqlDialect{}
+	assert.Equal(t, "FOR UPDATE", mysql.Lock(false, ""))
+	assert.Equal(t, "LOCK IN SHARE MODE", mysql.Lock(true, ""))
+	assert.Equal(t, "FOR SHARE SKIP LOCKED", mysql.Lock(true, "SKIP LOCKED"))
+	assert.Equal(t, "FOR UPDATE NOWAIT", mysql.Lock(false, "NOWAIT"))
+
+	postgres := postgresDialect{}
+	assert.Equal(t, "FOR SHARE", postgres.Lock(true, ""))
+	assert.Equal(t, "FOR UPDATE SKIP LOCKED", postgres.Lock(false, "SKIP LOCKED"))
+
+	var d Dialect = sqliteDialect{}
+	_, ok := d.(Locker)
+	assert.False(t, ok)
+}
+
+func TestStmtLiteral(t *testing.T) {
+	tt, _ := time.ParseInLocation(FTimeDateTime, "2021-01-01 01:01:01", time.Local)
+	mysql := newDialectStmt(mysqlDialect{}).(*StmtMysql)
+	assert.Equal(t, "NULL", mysql.literal(nil))
+	assert.Equal(t, "TRUE", mysql.literal(true))
+	assert.Equal(t, "-12", mysql.literal(int8(-12)))
+	assert.Equal(t, "1.5", mysql.literal(1.5))
+	assert.Equal(t, "'2021-01-01 01:01:01'", mysql.literal(tt))
+	assert.Equal(t, `'it''s \\n'`, mysql.literal(`it's \n`))
+	assert.Equal(t, "X'0aff'", mysql.literal([]byte{10, 255}))
+	assert.Equal(t, "'tom'", mysql.literal(sql.NullString{String: "tom", Valid: true}))
+	assert.Equal(t, "NULL", mysql.literal(sql.NullString{}))
+	assert.Equal(t, "'1s'", mysql.literal(time.Second))
+
+	postgres := newDialectStmt(postgresDialect{}).(*StmtPostgres)
+	assert.Equal(t, `'it''s \n'`, postgres.literal(`it's \n`))
+	assert.Equal(t, `'\x0aff'`, postgres.literal([]byte{10, 255}))
+
+	sqlserver := newDialectStmt(sqlserverDialect{}).(*StmtSqlserver)
+	assert.Equal(t, "0", sqlserver.literal(false))
+	assert.Equal(t, "0x0aff", sqlserver.literal([]byte{10, 255}))
+
+	sqlite := newDialectStmt(sqliteDialect{}).(*StmtSqlite)
+	assert.Equal(t, `'it''s \n'`, sqlite.literal(`it's \n`))
+	assert.Equal(t, "TRUE", sqlite.literal(true))
+	assert.Equal(t, "X'0aff'", sqlite.literal([]byte{10, 255}))
+
+	//the literals of the wrapped dialects
+	wrapped := newDialectStmt(testDialect{mysqlDialect{}}).(*stmt)
+	assert.Equal(t, `'\\'' OR 1=1 -- '`, wrapped.literal(`\' OR 1=1 -- `))
+	wrapped = newDialectStmt(testDialect{sqlserverDialect{}}).(*stmt)
+	assert.Equal(t, "1", wrapped.literal(true))
+	assert.Equal(t, "0x0aff", wrapped.literal([]byte{10, 255}))
+}
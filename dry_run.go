@@ -0,0 +1,100 @@
+package edb
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// ErrDryRun a write of the default manager refused during the package-level DryRun,
+// the writes of the closure go through its dry manager
+var ErrDryRun = errors.New("edb: write refused during DryRun, use the dry manager of the closure")
+
+type (
+
+	// Plan the statements recorded instead of executed by DryRun
+	Plan struct {
+		mu         sync.Mutex
+		statements []PlanStatement
+	}
+
+	// PlanStatement a recorded statement
+	PlanStatement struct {
+		// ConnectName the connection of the statement, "" is the default connection
+		ConnectName string
+		SQL         string
+		Bindings    []interface{}
+	}
+
+	// dryRunResult the result of a recorded statement, no rows affected, insert id 0
+	dryRunResult struct{}
+)
+
+var _ sql.Result = dryRunResult{}
+
+// DryRun pass the closure a dry manager sharing the connections of m, the Insert, Update, Delete and Exec
+// of its models, connections and transactions are recorded instead of executed, the queries are still executed.
+// The transactions of the dry manager do not begin, m itself is not affected
+//
+// Example usage:
+//
+// (
+// 	plan := mgr.DryRun(func(dry *edb.Manager) {
+// 		m, _ := dry.New(&User{})
+// 		m.Eq("name", "tom").Delete()
+// 	})
+// 	for _, s := range plan.Statements() {
+// 		fmt.Println(s.SQL, s.Bindings)
+// 	}
+// )
+func (m *Manager) DryRun(closure func(dry *Manager)) *Plan {
+	plan := &Plan{statements: make([]PlanStatement, 0)}
+	closure(&Manager{connect: m.connect, plan: plan})
+	return plan
+}
+
+// dryRun record the statement if the manager is the dry manager of DryRun,
+// refuse it with ErrDryRun if the package-level DryRun is in progress on the manager
+func (m *Manager) dryRun(connectName string, query string, bindings []interface{}) (sql.Result, bool, error) {
+	if m.plan != nil {
+		m.plan.record(PlanStatement{ConnectName: connectName, SQL: query, Bindings: bindings})
+		return dryRunResult{}, true, nil
+	}
+	if atomic.LoadInt32(&m.refusing) > 0 {
+		return nil, true, ErrDryRun
+	}
+	return nil, false, nil
+}
+
+// Statements the recorded statements in order
+func (p *Plan) Statements() []PlanStatement {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]PlanStatement(nil), p.statements...)
+}
+
+// String one statement per line, the sql followed by the bindings
+func (p *Plan) String() string {
+	sqlBuffer := new(strings.Builder)
+	for _, s := range p.Statements() {
+		sqlBuffer.WriteString(fmt.Sprintf("%s %v\n", s.SQL, s.Bindings))
+	}
+	return sqlBuffer.String()
+}
+
+func (p *Plan) record(s PlanStatement) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.statements = append(p.statements, s)
+}
+
+func (dryRunResult) LastInsertId() (int64, error) {
+	return 0, nil
+}
+
+func (dryRunResult) RowsAffected() (int64, error) {
+	return 0, nil
+}
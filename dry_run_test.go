@@ -0,0 +1,118 @@
+package edb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDryRun(t *testing.T) {
+	testBoot(t)
+
+	type User struct {
+		Id   int `type:"autoPk"`
+		Name string
+		Age  int
+	}
+
+	m, err := New(&User{})
+	assert.Nil(t, err)
+	m.Exec("DELETE FROM `user`;")
+	mu, err := New(&User{Name: "tom", Age: 10})
+	assert.Nil(t, err)
+	_, err = mu.Insert()
+	assert.Nil(t, err)
+
+	var count int64
+	plan := DryRun(func(dry *Manager) {
+		m, err := dry.New(&User{Name: "jerry", Age: 20})
+		assert.Nil(t, err)
+		id, err := m.Insert()
+		assert.Nil(t, err)
+		assert.Equal(t, int64(0), id)
+
+		rowAffected, err := m.Set("age", Expr("`age` + ?", 1)).Eq("name", "tom").Update(nil)
+		assert.Nil(t, err)
+		assert.Equal(t, int64(0), rowAffected)
+
+		_, err = m.Eq("name", "tom").Delete()
+		assert.Nil(t, err)
+
+		_, err = dry.Exec("UPDATE `user` SET `age` = ?;", 1)
+		assert.Nil(t, err)
+
+		//the queries are executed
+		count, err = m.Count()
+		assert.Nil(t, err)
+
+		//the transaction does not begin, its queries run on the primary
+		err = dry.Transaction(func(tx *Tx) error {
+			assert.Nil(t, tx.Tx())
+			_, err := tx.Exec("DELETE FROM `user` WHERE `id` = ?;", 1)
+			if err != nil {
+				return err
+			}
+			m, err := tx.New(&User{})
+			if err != nil {
+				return err
+			}
+			e, err := m.Eq("id", 1).First()
+			assert.NotNil(t, e)
+			if err != nil {
+				return err
+			}
+			//no savepoint
+			return tx.Transaction(func(tx *Tx) error {
+				_, err := tx.Exec("DELETE FROM `user`;")
+				return err
+			})
+		})
+		assert.Nil(t, err)
+
+		//the writes of the default manager are refused, the queries still run
+		mt, err := New(&User{Name: "spike", Age: 30})
+		assert.Nil(t, err)
+		_, err = mt.Insert()
+		assert.ErrorIs(t, err, ErrDryRun)
+		_, err = mt.Eq("name", "tom").Delete()
+		assert.ErrorIs(t, err, ErrDryRun)
+		_, err = Conn("").Exec("DELETE FROM `user`;")
+		assert.ErrorIs(t, err, ErrDryRun)
+		err = Transaction(func(tx *Tx) error {
+			_, err := tx.Exec("DELETE FROM `user`;")
+			return err
+		})
+		assert.ErrorIs(t, err, ErrDryRun)
+		e, err := mt.Eq("name", "tom").First()
+		assert.Nil(t, err)
+		assert.NotNil(t, e)
+	})
+	assert.Equal(t, int64(1), count)
+
+	statements := plan.Statements()
+	assert.Equal(t, 6, len(statements))
+	assert.Contains(t, statements[0].SQL, "INSERT INTO \"user\" (")
+	assert.ElementsMatch(t, []interface{}{"jerry", 20}, statements[0].Bindings)
+	assert.Equal(t, "UPDATE \"user\" SET \"age\" = `age` + ? WHERE \"name\" = ? ;", statements[1].SQL)
+	assert.Equal(t, []interface{}{1, "tom"}, statements[1].Bindings)
+	assert.Equal(t, "DELETE FROM \"user\" WHERE \"name\" = ? ;", statements[2].SQL)
+	assert.Equal(t, PlanStatement{SQL: "UPDATE `user` SET `age` = ?;", Bindings: []interface{}{1}}, statements[3])
+	assert.Equal(t, "DELETE FROM `user` WHERE `id` = ?;", statements[4].SQL)
+	assert.Equal(t, "DELETE FROM `user`;", statements[5].SQL)
+	assert.Contains(t, plan.String(), "DELETE FROM \"user\" WHERE \"name\" = ? ; [tom]\n")
+
+	//nothing of the dry manager is written
+	item, err := m.First()
+	assert.Nil(t, err)
+	assert.Equal(t, &User{Id: 1, Name: "tom", Age: 10}, item)
+	count, err = m.Count()
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), count)
+
+	//executed after the dry run
+	_, err = m.Eq("name", "tom").Delete()
+	assert.Nil(t, err)
+	count, err = m.Count()
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), count)
+}
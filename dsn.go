@@ -0,0 +1,327 @@
+package edb
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// mysqlDSN build the go-sql-driver/mysql data source name
+// [username[:password]@][protocol[(address)]]/dbname[?param1=value1&...&paramN=valueN]
+//
+// the password is written as it is, the driver takes the last '@' before the last '/'
+// as the end of the password, so '@', '/' and ':' in the password need no escaping,
+// param values are query escaped
+func mysqlDSN(c *Config) string {
+	buffer := new(strings.Builder)
+
+	if c.Username != "" || c.Password != "" {
+		buffer.WriteString(c.Username)
+		if c.Password != "" {
+			buffer.WriteString(":" + c.Password)
+		}
+		buffer.WriteString("@")
+	}
+
+	if c.Socket != "" {
+		buffer.WriteString("unix(" + c.Socket + ")")
+	} else {
+		addr := c.Host
+		if c.Port != "" {
+			addr = net.JoinHostPort(c.Host, c.Port)
+		}
+		buffer.WriteString("tcp(" + addr + ")")
+	}
+	buffer.WriteString("/" + c.Database)
+
+	params := make([]string, 0)
+	add := func(k, v string) {
+		params = append(params, k+"="+url.QueryEscape(v))
+	}
+	if c.Charset != "" {
+		add("charset", c.Charset)
+	}
+	if c.Collation != "" {
+		add("collation", c.Collation)
+	}
+	if c.ParseTime {
+		add("parseTime", "true")
+	}
+	if c.Loc != "" {
+		add("loc", c.Loc)
+	}
+	if c.Timeout > 0 {
+		add("timeout", c.Timeout.String())
+	}
+	if c.ReadTimeout > 0 {
+		add("readTimeout", c.ReadTimeout.String())
+	}
+	if c.WriteTimeout > 0 {
+		add("writeTimeout", c.WriteTimeout.String())
+	}
+	if c.TLS != "" {
+		add("tls", c.TLS)
+	}
+	keys := make([]string, 0, len(c.Params))
+	for k := range c.Params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		add(k, c.Params[k])
+	}
+
+	if len(params) > 0 {
+		buffer.WriteString("?" + strings.Join(params, "&"))
+	}
+	return buffer.String()
+}
+
+// ParseDSN parse a go-sql-driver/mysql data source name into *Config, the reverse of Config.DNS
+//
+// Example usage:
+//
+// (
+// 	c, err := edb.ParseDSN("root:p@ss/word@tcp(127.0.0.1:3306)/test?charset=utf8mb4&parseTime=true")
+// 	edb.AddConfig("default", c)
+// )
+func ParseDSN(dsn string) (*Config, error) {
+	c := &Config{
+		Driver: DriverMysql,
+	}
+
+	//the last '/', the password or the address may contain '/'
+	slash := strings.LastIndex(dsn, "/")
+	if slash < 0 {
+		return nil, errors.New("edb ParseDSN err: missing the slash before the database name")
+	}
+
+	left := dsn[:slash]
+	if at := strings.LastIndex(left, "@"); at >= 0 {
+		user := left[:at]
+		if colon := strings.Index(user, ":"); colon >= 0 {
+			c.Username = user[:colon]
+			c.Password = user[colon+1:]
+		} else {
+			c.Username = user
+		}
+		left = left[at+1:]
+	}
+
+	if left != "" {
+		protocol, addr := left, ""
+		if i := strings.Index(left, "("); i >= 0 {
+			if !strings.HasSuffix(left, ")") {
+				return nil, errors.New("edb ParseDSN err: invalid network address, missing the closing bracket")
+			}
+			protocol, addr = left[:i], left[i+1:len(left)-1]
+		}
+		switch protocol {
+		case "unix":
+			c.Socket = addr
+		case "tcp":
+			if addr != "" {
+				host, port, err := net.SplitHostPort(addr)
+				if err != nil {
+					c.Host = addr
+				} else {
+					c.Host, c.Port = host, port
+				}
+			}
+		default:
+			return nil, fmt.Errorf("edb ParseDSN err: unsupported protocol %s", protocol)
+		}
+	}
+
+	right := dsn[slash+1:]
+	query := ""
+	if q := strings.Index(right, "?"); q >= 0 {
+		right, query = right[:q], right[q+1:]
+	}
+	c.Database = right
+
+	if query == "" {
+		return c, nil
+	}
+	for _, kv := range strings.Split(query, "&") {
+		if kv == "" {
+			continue
+		}
+		k, v := kv, ""
+		if i := strings.Index(kv, "="); i >= 0 {
+			k, v = kv[:i], kv[i+1:]
+		}
+		value, err := url.QueryUnescape(v)
+		if err != nil {
+			return nil, fmt.Errorf("edb ParseDSN err: invalid value of param %s", k)
+		}
+		if err := c.setParam(k, value); err != nil {
+			return nil, fmt.Errorf("edb ParseDSN err: %s", err.Error())
+		}
+	}
+	return c, nil
+}
+
+// setParam set the config field of a dsn param, unknown params go to Config.Params
+func (c *Config) setParam(k, value string) (err error) {
+	switch k {
+	case "charset":
+		c.Charset = value
+	case "collation":
+		c.Collation = value
+	case "parseTime":
+		c.ParseTime, err = strconv.ParseBool(value)
+	case "loc":
+		c.Loc = value
+	case "timeout":
+		c.Timeout, err = time.ParseDuration(value)
+	case "readTimeout":
+		c.ReadTimeout, err = time.ParseDuration(value)
+	case "writeTimeout":
+		c.WriteTimeout, err = time.ParseDuration(value)
+	case "tls":
+		c.TLS = value
+	default:
+		if c.Params == nil {
+			c.Params = make(map[string]string)
+		}
+		c.Params[k] = value
+	}
+	if err != nil {
+		return fmt.Errorf("invalid value of param %s", k)
+	}
+	return nil
+}
+
+// postgresDSN build the lib/pq and pgx url data source name
+// postgres://[username[:password]@][host[:port]]/dbname[?param1=value1&...&paramN=valueN]
+//
+// Charset is client_encoding, TLS is sslmode, Timeout is connect_timeout in seconds,
+// Socket is the host param, the directory of the unix socket
+func postgresDSN(c *Config) string {
+	u := &url.URL{
+		Scheme: DriverPostgres,
+		Host:   c.Host,
+		Path:   "/" + c.Database,
+	}
+	if c.Port != "" {
+		u.Host = net.JoinHostPort(c.Host, c.Port)
+	}
+	if c.Password != "" {
+		u.User = url.UserPassword(c.Username, c.Password)
+	} else if c.Username != "" {
+		u.User = url.User(c.Username)
+	}
+
+	params := url.Values{}
+	if c.Socket != "" {
+		params.Set("host", c.Socket)
+	}
+	if c.Charset != "" {
+		params.Set("client_encoding", c.Charset)
+	}
+	if c.TLS != "" {
+		params.Set("sslmode", c.TLS)
+	}
+	if c.Timeout > 0 {
+		//round up, 0 is wait indefinitely
+		params.Set("connect_timeout", strconv.FormatInt(int64((c.Timeout+time.Second-1)/time.Second), 10))
+	}
+	for k, v := range c.Params {
+		params.Set(k, v)
+	}
+	u.RawQuery = params.Encode()
+	return u.String()
+}
+
+var (
+	// memoryMu memoryDatabases counter of the sqlite :memory: databases
+	memoryMu        sync.Mutex
+	memoryDatabases int
+)
+
+// memoryName the unique name of the sqlite :memory: database of the config
+func memoryName(c *Config) string {
+	memoryMu.Lock()
+	defer memoryMu.Unlock()
+	if c.memoryName == "" {
+		memoryDatabases++
+		c.memoryName = "edb_memory_" + strconv.Itoa(memoryDatabases)
+	}
+	return c.memoryName
+}
+
+// sqliteDSN build the mattn/go-sqlite3 data source name, the file path or :memory:,
+// :memory: is one database shared by the connections of the pool, other configs and managers
+// have their own, it is gone when the last connection is closed
+//
+// Loc is _loc, default auto, times are read in time.Local as they are written,
+// Timeout is _busy_timeout
+func sqliteDSN(c *Config) string {
+	if c.Database == "" {
+		return ""
+	}
+
+	dsn := c.Database
+	params := url.Values{}
+	if dsn == ":memory:" {
+		dsn = "file:" + memoryName(c)
+		params.Set("mode", "memory")
+		params.Set("cache", "shared")
+	}
+	switch c.Loc {
+	case "", "Local":
+		params.Set("_loc", "auto")
+	default:
+		params.Set("_loc", c.Loc)
+	}
+	if c.Timeout > 0 {
+		params.Set("_busy_timeout", strconv.FormatInt(c.Timeout.Milliseconds(), 10))
+	}
+	for k, v := range c.Params {
+		params.Set(k, v)
+	}
+	return dsn + "?" + params.Encode()
+}
+
+// sqlserverDSN build the go-mssqldb url data source name
+// sqlserver://[username[:password]@]host[:port]?database=dbname[&param1=value1&...&paramN=valueN]
+//
+// TLS is encrypt, Timeout is dial timeout in seconds
+func sqlserverDSN(c *Config) string {
+	u := &url.URL{
+		Scheme: DriverSqlserver,
+		Host:   c.Host,
+	}
+	if c.Port != "" {
+		u.Host = net.JoinHostPort(c.Host, c.Port)
+	}
+	if c.Password != "" {
+		u.User = url.UserPassword(c.Username, c.Password)
+	} else if c.Username != "" {
+		u.User = url.User(c.Username)
+	}
+
+	params := url.Values{}
+	if c.Database != "" {
+		params.Set("database", c.Database)
+	}
+	if c.TLS != "" {
+		params.Set("encrypt", c.TLS)
+	}
+	if c.Timeout > 0 {
+		//round up, 0 is no timeout
+		params.Set("dial timeout", strconv.FormatInt(int64((c.Timeout+time.Second-1)/time.Second), 10))
+	}
+	for k, v := range c.Params {
+		params.Set(k, v)
+	}
+	u.RawQuery = params.Encode()
+	return u.String()
+}
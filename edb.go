@@ -131,7 +131,11 @@ Example:
 */
 package edb
 
-import "fmt"
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+)
 
 const (
 	// OPSelect select
@@ -142,6 +146,8 @@ const (
 	OPUpdate
 	// OPInsert insert
 	OPInsert
+	// OPCount count the rows of the select, for Paginate
+	OPCount
 )
 
 // structrue tag
@@ -160,24 +166,84 @@ const (
 
 // AddConfig add database connection configuration
 func AddConfig(connectName string, config *Config) bool {
-	return manager.connect.AddConfig(connectName, config)
+	return manager.AddConfig(connectName, config)
+}
+
+// LoadConfigFile add the connection configurations of a .yaml, .yml or .json file
+func LoadConfigFile(path string) error {
+	return manager.LoadConfigFile(path)
+}
+
+// ConfigFromEnv add the connection configurations of the environment variables with prefix
+func ConfigFromEnv(prefix string) error {
+	return manager.ConfigFromEnv(prefix)
+}
+
+// ConfigFromURL add the default connection configuration of a url
+func ConfigFromURL(rawURL string) error {
+	return manager.ConfigFromURL(rawURL)
 }
 
-// Boot start up
+// Boot start up, the first booted connection is the default connection,
+// boot other connections and use them through Conn or Model.On.
+// Boot panics if the connection fails, use Open to get the error
 func Boot(connectName string) {
-	if err := manager.connect.Connect(connectName); err != nil {
-		panic(err.Error())
-	}
+	manager.Boot(connectName)
+}
+
+// Open connect to the database, the first opened connection is the default connection
+func Open(connectName string) error {
+	return manager.Open(connectName)
+}
+
+// Stats statistics and health of the named connection pool, "" is the default connection,
+// eg: for a readiness probe
+func Stats(connectName string) (*PoolStats, error) {
+	return manager.Stats(connectName)
+}
+
+// Close close every connection of the default manager
+func Close() error {
+	return manager.Close()
+}
+
+// Shutdown wait for the statements and transactions in progress until ctx is done,
+// then close every connection of the default manager
+func Shutdown(ctx context.Context) error {
+	return manager.Shutdown(ctx)
+}
+
+// Transaction run closure in a transaction on the current connection,
+// models created by tx.New run on the transaction
+func Transaction(closure func(tx *Tx) error) error {
+	return manager.Transaction(closure)
+}
+
+// TransactionContext Transaction with context on the current connection,
+// a savepoint is created instead if ctx carries a transaction of the connection, see Tx.Context
+func TransactionContext(ctx context.Context, closure func(tx *Tx) error) error {
+	return manager.TransactionContext(ctx, closure)
+}
+
+// DryRun pass the closure a dry manager of the default manager, the Insert, Update, Delete
+// and Exec of the dry manager are recorded instead of executed, see Manager.DryRun.
+// Until it returns, the writes of the default manager itself, eg: models of edb.New,
+// are refused with ErrDryRun instead of executed
+func DryRun(closure func(dry *Manager)) *Plan {
+	atomic.AddInt32(&manager.refusing, 1)
+	defer atomic.AddInt32(&manager.refusing, -1)
+	return manager.DryRun(closure)
+}
+
+// Conn get the named connection
+func Conn(connectName string) *Connection {
+	return manager.Conn(connectName)
 }
 
-func newStmt() (stmt Stmt, err error) {
-	switch manager.connect.driver {
-	case DriverMysql:
-		stmt = &StmtMysql{
-			bindings: make([]interface{}, 0),
-		}
-	default:
-		err = fmt.Errorf("edb Model.New err: unsupported %s driver syntax", manager.connect.driver)
+func newStmt(driver string) (stmt Stmt, err error) {
+	d, ok := LookupDialect(driver)
+	if !ok {
+		return nil, fmt.Errorf("edb Model.New err: unsupported %s driver syntax", driver)
 	}
-	return
+	return newDialectStmt(d), nil
 }
@@ -1,11 +1,15 @@
 package edb
 
 import (
+	"net"
+	"reflect"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	//_ test
 	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/mattn/go-sqlite3"
 )
 
 func TestEdbAddConfig(t *testing.T) {
@@ -29,6 +33,8 @@ func TestEdbAddConfig(t *testing.T) {
 }
 
 func TestBoot(t *testing.T) {
+	skipWithoutMysql(t)
+
 	res := AddConfig("default", &Config{
 		Driver:   "mysql",
 		Host:     "127.0.0.1",
@@ -57,3 +63,74 @@ func TestBoot(t *testing.T) {
 		Boot("default")
 	})
 }
+
+// skipWithoutMysql skip the test if there is no mysql server at 127.0.0.1:3306
+func skipWithoutMysql(t *testing.T) {
+	conn, err := net.DialTimeout("tcp", "127.0.0.1:3306", time.Second)
+	if err != nil {
+		t.Skip("mysql server at 127.0.0.1:3306 is not available")
+	}
+	conn.Close()
+}
+
+// testBoot boot the default connection on an in-memory sqlite database with the user table,
+// the model tests run without a mysql server
+func testBoot(t *testing.T) {
+	res := AddConfig("default", &Config{Driver: "sqlite3", Database: ":memory:"})
+	assert.True(t, res)
+	assert.NotPanics(t, func() {
+		Boot("default")
+	})
+
+	_, err := manager.Exec("CREATE TABLE IF NOT EXISTS `user` (`id` INTEGER PRIMARY KEY, `name` varchar(50) DEFAULT '', `age` int DEFAULT 0, `created_at` datetime DEFAULT NULL, `updated_at` datetime DEFAULT NULL);")
+	assert.Nil(t, err)
+}
+
+// testSeed empty the table, then insert the entities by their models
+func testSeed(t *testing.T, table string, entities ...interface{}) {
+	_, err := manager.Exec("DELETE FROM `" + table + "`;")
+	assert.Nil(t, err)
+	for _, e := range entities {
+		m, err := New(e)
+		assert.Nil(t, err)
+		_, err = m.Insert()
+		assert.Nil(t, err)
+	}
+}
+
+// testRows read the collects of the tests to the end
+type testRows struct {
+	t *testing.T
+}
+
+// items the items of the collect
+func (r testRows) items(collect *Collect, err error) []interface{} {
+	assert.Nil(r.t, err)
+	items := make([]interface{}, 0)
+	if collect == nil {
+		return items
+	}
+	for collect.Next() {
+		items = append(items, collect.Item())
+	}
+	assert.Nil(r.t, collect.Err())
+	return items
+}
+
+// names the Name field of the items
+func (r testRows) names(collect *Collect, err error) []string {
+	names := make([]string, 0)
+	for _, item := range r.items(collect, err) {
+		names = append(names, reflect.ValueOf(item).Elem().FieldByName("Name").String())
+	}
+	return names
+}
+
+// ages the Age field of the items
+func (r testRows) ages(collect *Collect, err error) []int {
+	ages := make([]int, 0)
+	for _, item := range r.items(collect, err) {
+		ages = append(ages, int(reflect.ValueOf(item).Elem().FieldByName("Age").Int()))
+	}
+	return ages
+}
@@ -0,0 +1,54 @@
+package edb
+
+type (
+
+	// Expression a raw sql expression with ? placeholders, bound as a value it is inlined,
+	// the placeholders are numbered by the dialect
+	Expression struct {
+		sql      string
+		bindings []interface{}
+	}
+)
+
+// Expr new a raw sql expression, usable as the value of conditions and Set
+//
+// Example usage:
+//
+// (
+// 	// UPDATE `post` SET `views` = `views` + ? WHERE `id` = ?
+// 	m.Set("views", edb.Expr("`views` + ?", 1)).Eq("id", 1).Update(nil)
+// )
+func Expr(sql string, bindings ...interface{}) *Expression {
+	return &Expression{sql: sql, bindings: bindings}
+}
+
+// SQL the raw sql
+func (e *Expression) SQL() string {
+	return e.sql
+}
+
+// Bindings the bind parameters of the placeholders
+func (e *Expression) Bindings() []interface{} {
+	return e.bindings
+}
+
+// SubQuery the select of a model used as a value, its sql and bindings are inlined
+type SubQuery struct {
+	builder *Builder
+	err     error
+}
+
+// Sub the select built by the model as a subquery, the model is reset for the next query
+//
+// Example usage:
+//
+// (
+// 	// WHERE `id` IN (SELECT `user_id` FROM `order` WHERE `amount` > ?)
+// 	c, err := users.In("id", edb.Sub(orders.Select([]string{"user_id"}).Gt("amount", 100))).Get()
+// )
+func Sub(m *Model) *SubQuery {
+	defer m.reset()
+
+	b := *m.builder
+	return &SubQuery{builder: &b, err: m.lastErr}
+}
@@ -3,6 +3,8 @@ module github.com/RLOFLS/edb
 go 1.16
 
 require (
-	github.com/go-sql-driver/mysql v1.6.0 // indirect
-	github.com/stretchr/testify v1.7.0 // indirect
+	github.com/go-sql-driver/mysql v1.6.0
+	github.com/mattn/go-sqlite3 v1.14.16
+	github.com/stretchr/testify v1.7.0
+	gopkg.in/yaml.v3 v3.0.1
 )
@@ -1,7 +1,9 @@
 package edb
 
 import (
+	"context"
 	"database/sql"
+	"fmt"
 )
 
 type (
@@ -9,32 +11,233 @@ type (
 	// Manager magage database connections
 	Manager struct {
 		connect *connect
+		// plan the statements are recorded instead of executed, the manager of DryRun
+		plan *Plan
+		// refusing the count of the package-level DryRun in progress, the writes are refused meanwhile
+		refusing int32
+	}
+
+	// Connection a named connection of the manager
+	Connection struct {
+		manager     *Manager
+		connectName string
+	}
+
+	// executor runs statements, implemented by *pool and *Tx
+	executor interface {
+		ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+		QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+		// queryContext QueryContext, call release after the rows are closed
+		queryContext(ctx context.Context, query string, args ...interface{}) (sqlRows *sql.Rows, release func(), err error)
 	}
 )
 
-var manager = &Manager{
-	connect: newConnect(),
+// manager the default manager used by the package-level functions
+var manager = NewManager()
+
+// NewManager new manager with its own connections, independent of the default manager
+//
+// Example usage:
+//
+// (
+// 	mgr := edb.NewManager()
+// 	mgr.AddConfig("default", &edb.Config{...})
+// 	mgr.Boot("default")
+// 	m, err := mgr.New(&User{})
+// )
+func NewManager() *Manager {
+	return &Manager{
+		connect: newConnect(),
+	}
+}
+
+// AddConfig add database connection configuration
+func (m *Manager) AddConfig(connectName string, config *Config) bool {
+	return m.connect.AddConfig(connectName, config)
+}
+
+// Boot start up, panic if the connection fails, see Open
+func (m *Manager) Boot(connectName string) {
+	if err := m.Open(connectName); err != nil {
+		panic(err.Error())
+	}
+}
+
+// Open connect to the database, the first opened connection is the default connection
+func (m *Manager) Open(connectName string) error {
+	return m.connect.Connect(connectName)
+}
+
+// Stats statistics and health of the named connection pool, "" is the default connection
+func (m *Manager) Stats(connectName string) (*PoolStats, error) {
+	return m.connect.Stats(connectName)
+}
+
+// Close close every connection, statements afterwards return ErrClosed
+func (m *Manager) Close() error {
+	return m.connect.Close()
+}
+
+// Shutdown refuse new statements, wait for the statements and transactions in progress
+// until ctx is done, then close every connection
+func (m *Manager) Shutdown(ctx context.Context) error {
+	return m.connect.Shutdown(ctx)
 }
 
-// Exec exec
+// New new model on the default connection of the manager
+func (m *Manager) New(entity interface{}) (*Model, error) {
+	return newModel(m, entity, "")
+}
+
+// Exec exec on the primary
 func (m *Manager) Exec(query string, bindings ...interface{}) (sql.Result, error) {
+	return m.ExecContext(context.Background(), query, bindings...)
+}
 
-	return m.connect.Exec(query, bindings...)
+// ExecContext exec on the primary with context
+func (m *Manager) ExecContext(ctx context.Context, query string, bindings ...interface{}) (sql.Result, error) {
+	return m.Conn("").ExecContext(ctx, query, bindings...)
 }
 
-// Query query
+// Query query, load-balanced across the replicas
 func (m *Manager) Query(query string, bindings ...interface{}) (*sql.Rows, error) {
-	return m.connect.Query(query, bindings...)
+	return m.QueryContext(context.Background(), query, bindings...)
+}
+
+// QueryContext query with context, load-balanced across the replicas
+func (m *Manager) QueryContext(ctx context.Context, query string, bindings ...interface{}) (*sql.Rows, error) {
+	return m.Conn("").QueryContext(ctx, query, bindings...)
 }
 
 // QueryCollect query and return *Collect
 func (m *Manager) QueryCollect(query string, bindings ...interface{}) (*Collect, error) {
+	return m.Conn("").QueryCollect(query, bindings...)
+}
+
+// Transaction run closure in a transaction, commit when the closure returns nil,
+// rollback when it returns an error or panics
+func (m *Manager) Transaction(closure func(tx *Tx) error) error {
+	return m.transaction(context.Background(), "", closure)
+}
+
+// TransactionContext Transaction with context, the context is used by the
+// statements of the transaction unless a model sets its own by WithContext,
+// a savepoint is created instead if ctx carries a transaction of the connection, see Tx.Context
+func (m *Manager) TransactionContext(ctx context.Context, closure func(tx *Tx) error) error {
+	return m.transaction(ctx, "", closure)
+}
 
-	sqlRows, err := m.connect.Query(query, bindings...)
+// Conn get the named connection
+func (m *Manager) Conn(connectName string) *Connection {
+	return &Connection{
+		manager:     m,
+		connectName: connectName,
+	}
+}
+
+func (m *Manager) transaction(ctx context.Context, connectName string, closure func(tx *Tx) error) error {
+	if outer, ok := contextTx(ctx); ok && outer.manager == m && m.sameConnect(outer.connectName, connectName) {
+		return outer.Transaction(closure)
+	}
+	if m.plan != nil {
+		//a dry run records the statements of the transaction without beginning it
+		tx := newTx(m, ctx, nil, connectName)
+		return runTransaction(tx, closure, func() error { return nil }, func() error { return nil })
+	}
+	p, err := m.connect.pool(connectName)
+	if err != nil {
+		return err
+	}
+	p.wrote(ctx)
+	sqlTx, err := p.BeginTx(ctx)
+	if err != nil {
+		return fmt.Errorf("edb Manager.Transaction err: %w", err)
+	}
+	tx := newTx(m, ctx, sqlTx, connectName)
+	tx.release = p.conn.release
+	return runTransaction(tx, closure, tx.commit, tx.rollback)
+}
+
+// sameConnect the connect names are the same pool, "" is the default connection
+func (m *Manager) sameConnect(a, b string) bool {
+	pa, err := m.connect.pool(a)
+	if err != nil {
+		return false
+	}
+	pb, err := m.connect.pool(b)
+	return err == nil && pa == pb
+}
+
+// New new model on the connection
+func (c *Connection) New(entity interface{}) (*Model, error) {
+	return newModel(c.manager, entity, c.connectName)
+}
+
+// Exec exec on the connection
+func (c *Connection) Exec(query string, bindings ...interface{}) (sql.Result, error) {
+	return c.ExecContext(context.Background(), query, bindings...)
+}
+
+// ExecContext exec on the connection with context
+func (c *Connection) ExecContext(ctx context.Context, query string, bindings ...interface{}) (sql.Result, error) {
+	if result, ok, err := c.manager.dryRun(c.connectName, query, bindings); ok {
+		return result, err
+	}
+	p, err := c.manager.connect.pool(c.connectName)
+	if err != nil {
+		return nil, err
+	}
+	p.wrote(ctx)
+	return p.ExecContext(ctx, query, bindings...)
+}
+
+// Query query on the connection, load-balanced across the replicas
+func (c *Connection) Query(query string, bindings ...interface{}) (*sql.Rows, error) {
+	return c.QueryContext(context.Background(), query, bindings...)
+}
+
+// QueryContext query on the connection with context, load-balanced across the replicas
+func (c *Connection) QueryContext(ctx context.Context, query string, bindings ...interface{}) (*sql.Rows, error) {
+	p, err := c.manager.connect.pool(c.connectName)
+	if err != nil {
+		return nil, err
+	}
+	return p.readerContext(ctx).QueryContext(ctx, query, bindings...)
+}
+
+// QueryCollect query on the connection and return *Collect
+func (c *Connection) QueryCollect(query string, bindings ...interface{}) (*Collect, error) {
+	p, err := c.manager.connect.pool(c.connectName)
+	if err != nil {
+		return nil, err
+	}
+	ctx := context.Background()
+	sqlRows, release, err := p.readerContext(ctx).queryContext(ctx, query, bindings...)
 	if err != nil {
 		return nil, err
 	}
 	return &Collect{
 		sqlRows: sqlRows,
+		release: release,
 	}, nil
 }
+
+// Transaction run closure in a transaction on the connection
+func (c *Connection) Transaction(closure func(tx *Tx) error) error {
+	return c.manager.transaction(context.Background(), c.connectName, closure)
+}
+
+// TransactionContext Transaction with context on the connection
+func (c *Connection) TransactionContext(ctx context.Context, closure func(tx *Tx) error) error {
+	return c.manager.transaction(ctx, c.connectName, closure)
+}
+
+// Db *sql.DB of the connection, nil if it is not connected
+func (c *Connection) Db() *sql.DB {
+	p, err := c.manager.connect.pool(c.connectName)
+	if err != nil {
+		return nil
+	}
+	return p.db
+}
+
@@ -0,0 +1,112 @@
+package edb
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	//_ test
+	_ "github.com/go-sql-driver/mysql"
+)
+
+func TestNewManager(t *testing.T) {
+	mgr := NewManager()
+	mgr2 := NewManager()
+
+	assert.True(t, mgr.AddConfig("default", &Config{Driver: "mysql", Host: "127.0.0.1", Port: "3306", Username: "root", Password: "12345678", Database: "test", Charset: "utf8"}))
+	_, ok := mgr.connect.config["default"]
+	assert.True(t, ok)
+	_, ok2 := mgr2.connect.config["default"]
+	assert.False(t, ok2)
+	assert.NotSame(t, manager, mgr)
+}
+
+func TestManagerNew(t *testing.T) {
+	type User struct {
+		Id        int `type:"autoPk"`
+		Name      string
+		Age       int
+		CreatedAt time.Time `type:"dateTime"`
+		UpdatedAt time.Time `type:"dateTime"`
+	}
+
+	mgr := NewManager()
+	mgr.AddConfig("default", &Config{Driver: "sqlite3", Database: ":memory:"})
+	mgr.Boot("default")
+	_, err := mgr.Exec("CREATE TABLE `user` (`id` INTEGER PRIMARY KEY, `name` varchar(50) DEFAULT '', `age` int DEFAULT 0, `created_at` datetime DEFAULT NULL, `updated_at` datetime DEFAULT NULL);")
+	assert.Nil(t, err)
+
+	m, err := mgr.New(&User{Name: "tom"})
+	assert.Nil(t, err)
+	assert.Same(t, mgr, m.manager)
+	id, err := m.Insert()
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), id)
+
+	err = mgr.Transaction(func(tx *Tx) error {
+		m2, err := tx.New(&User{})
+		assert.Same(t, mgr, m2.manager)
+		return err
+	})
+	assert.Nil(t, err)
+
+	//not booted
+	mgr2 := NewManager()
+	_, err = mgr2.New(&User{})
+	assert.EqualError(t, err, "edb Model.New err: unsupported  driver syntax")
+}
+
+func TestManagerShutdownOpenRows(t *testing.T) {
+	type User struct {
+		Id   int `type:"autoPk"`
+		Name string
+	}
+
+	mgr := NewManager()
+	mgr.AddConfig("default", &Config{Driver: "sqlite3", Database: ":memory:"})
+	mgr.Boot("default")
+	_, err := mgr.Exec("CREATE TABLE `user` (`id` INTEGER PRIMARY KEY, `name` varchar(50) DEFAULT '');")
+	assert.Nil(t, err)
+	_, err = mgr.Exec("INSERT INTO `user` (`name`) VALUES ('tom'), ('jerry'), ('spike');")
+	assert.Nil(t, err)
+
+	m, err := mgr.New(&User{})
+	assert.Nil(t, err)
+	c, err := m.OrderBy("id").Get()
+	assert.Nil(t, err)
+	assert.True(t, c.Next())
+
+	//Shutdown waits for the rows being read
+	done := make(chan error)
+	go func() {
+		done <- mgr.Shutdown(context.Background())
+	}()
+	select {
+	case <-done:
+		t.Fatal("Shutdown returned while the rows are open")
+	case <-time.After(time.Millisecond * 50):
+	}
+
+	names := []string{c.Item().(*User).Name}
+	for c.Next() {
+		names = append(names, c.Item().(*User).Name)
+	}
+	assert.Nil(t, c.Err())
+	assert.Equal(t, []string{"tom", "jerry", "spike"}, names)
+	assert.Nil(t, <-done)
+
+	//closing the rows early also ends the wait
+	mgr2 := NewManager()
+	mgr2.AddConfig("default", &Config{Driver: "sqlite3", Database: ":memory:"})
+	mgr2.Boot("default")
+	c2, err := mgr2.Conn("").QueryCollect("SELECT 1 UNION ALL SELECT 2;")
+	assert.Nil(t, err)
+	go func() {
+		time.Sleep(time.Millisecond * 20)
+		c2.Close()
+	}()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	assert.Nil(t, mgr2.Shutdown(ctx))
+}
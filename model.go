@@ -1,15 +1,15 @@
 package edb
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"reflect"
 	"strings"
 	"unicode"
 )
 
-var edbManager *Manager
-
 type (
 
 	// Model model
@@ -23,6 +23,11 @@ type (
 		isAuto       bool
 		lastErr      error
 		stmt         Stmt
+		manager      *Manager
+		tx           *Tx
+		ctx          context.Context
+		connectName  string
+		usePrimary   bool
 		//todo
 		isChange bool
 	}
@@ -64,15 +69,21 @@ var supportTypes = SupportTypes{
 
 var _ Query = &Model{}
 
-// New new model, and init someting
+// New new model on the default connection of the default manager, and init someting
 func New(entity interface{}) (m *Model, err error) {
+	return manager.New(entity)
+}
+
+func newModel(mgr *Manager, entity interface{}, connectName string) (m *Model, err error) {
 	m = &Model{
 		builder:      NewBuilder(),
 		entity:       entity,
 		entityFields: make(map[string]Field, 50),
+		manager:      mgr,
+		connectName:  connectName,
 	}
 	m.builder.model = m
-	m.stmt, err = newStmt()
+	m.stmt, err = newStmt(mgr.connect.driver(connectName))
 	if err != nil {
 		return
 	}
@@ -89,6 +100,48 @@ func New(entity interface{}) (m *Model, err error) {
 	return
 }
 
+// On use the named connection for the following queries
+//
+// Example usage:
+//
+// (
+// 	c, err := m.On("reporting").Gt("age", 18).Get()
+// )
+func (m *Model) On(connectName string) *Model {
+	driver := m.manager.connect.driver(connectName)
+	if driver == "" {
+		m.lastErr = fmt.Errorf("edb Model.On err: %s : database connection configuration dose not exist", connectName)
+		return m
+	}
+	stmt, err := newStmt(driver)
+	if err != nil {
+		m.lastErr = err
+		return m
+	}
+	stmt.SetBuilder(m.builder)
+	m.stmt = stmt
+	m.connectName = connectName
+	return m
+}
+
+// WithContext use ctx for the following queries, cancellation and deadlines reach the driver
+//
+// Example usage:
+//
+// (
+// 	c, err := m.WithContext(r.Context()).Gt("age", 18).Get()
+// )
+func (m *Model) WithContext(ctx context.Context) *Model {
+	m.ctx = ctx
+	return m
+}
+
+// UsePrimary read from the primary instead of the replicas for the next query
+func (m *Model) UsePrimary() *Model {
+	m.usePrimary = true
+	return m
+}
+
 // Select select fields
 func (m *Model) Select(s []string) *Model {
 	if err := m.builder.Select(s); err != nil {
@@ -97,6 +150,12 @@ func (m *Model) Select(s []string) *Model {
 	return m
 }
 
+// SelectRaw select the raw expression, eg: SelectRaw("count(*) AS total")
+func (m *Model) SelectRaw(sql string, bindings ...interface{}) *Model {
+	m.builder.SelectRaw(sql, bindings...)
+	return m
+}
+
 // Eq Eq("name", "tom") => name='tom'
 func (m *Model) Eq(field string, value interface{}) *Model {
 	if err := m.builder.WhereCondition(field, "=", value); err != nil {
@@ -153,6 +212,344 @@ func (m *Model) Like(field string, value interface{}) *Model {
 	return m
 }
 
+// OrEq OrEq("name", "tom") => OR `name` = 'tom'
+func (m *Model) OrEq(field string, value interface{}) *Model {
+	if err := m.builder.OrWhereCondition(field, "=", value); err != nil {
+		m.lastErr = err
+	}
+	return m
+}
+
+// OrNeq OrNeq("name", "tom") => OR `name` != 'tom'
+func (m *Model) OrNeq(field string, value interface{}) *Model {
+	if err := m.builder.OrWhereCondition(field, "!=", value); err != nil {
+		m.lastErr = err
+	}
+	return m
+}
+
+// OrLt OrLt("age", 1) => OR `age` < 1
+func (m *Model) OrLt(field string, value interface{}) *Model {
+	if err := m.builder.OrWhereCondition(field, "<", value); err != nil {
+		m.lastErr = err
+	}
+	return m
+}
+
+// OrLte OrLte("age", 1) => OR `age` <= 1
+func (m *Model) OrLte(field string, value interface{}) *Model {
+	if err := m.builder.OrWhereCondition(field, "<=", value); err != nil {
+		m.lastErr = err
+	}
+	return m
+}
+
+// OrGt OrGt("age", 1) => OR `age` > 1
+func (m *Model) OrGt(field string, value interface{}) *Model {
+	if err := m.builder.OrWhereCondition(field, ">", value); err != nil {
+		m.lastErr = err
+	}
+	return m
+}
+
+// OrGte OrGte("age", 1) => OR `age` >= 1
+func (m *Model) OrGte(field string, value interface{}) *Model {
+	if err := m.builder.OrWhereCondition(field, ">=", value); err != nil {
+		m.lastErr = err
+	}
+	return m
+}
+
+// OrLike OrLike("name", "%sss") => OR `name` LIKE '%sss'
+func (m *Model) OrLike(field string, value interface{}) *Model {
+	if err := m.builder.OrWhereCondition(field, "LIKE", value); err != nil {
+		m.lastErr = err
+	}
+	return m
+}
+
+// NotLike NotLike("name", "%sss") => `name` NOT LIKE '%sss'
+func (m *Model) NotLike(field string, value interface{}) *Model {
+	if err := m.builder.WhereCondition(field, "NOT LIKE", value); err != nil {
+		m.lastErr = err
+	}
+	return m
+}
+
+// In In("id", []int{1, 2}) => `id` IN (1, 2), an empty slice matches nothing
+func (m *Model) In(field string, values interface{}) *Model {
+	if err := m.builder.WhereCondition(field, "IN", values); err != nil {
+		m.lastErr = err
+	}
+	return m
+}
+
+// NotIn NotIn("id", []int{1, 2}) => `id` NOT IN (1, 2), an empty slice matches all
+func (m *Model) NotIn(field string, values interface{}) *Model {
+	if err := m.builder.WhereCondition(field, "NOT IN", values); err != nil {
+		m.lastErr = err
+	}
+	return m
+}
+
+// Between Between("age", 1, 10) => `age` BETWEEN 1 AND 10
+func (m *Model) Between(field string, lo interface{}, hi interface{}) *Model {
+	if err := m.builder.WhereCondition(field, "BETWEEN", []interface{}{lo, hi}); err != nil {
+		m.lastErr = err
+	}
+	return m
+}
+
+// NotBetween NotBetween("age", 1, 10) => `age` NOT BETWEEN 1 AND 10
+func (m *Model) NotBetween(field string, lo interface{}, hi interface{}) *Model {
+	if err := m.builder.WhereCondition(field, "NOT BETWEEN", []interface{}{lo, hi}); err != nil {
+		m.lastErr = err
+	}
+	return m
+}
+
+// IsNull IsNull("name") => `name` IS NULL
+func (m *Model) IsNull(field string) *Model {
+	if err := m.builder.WhereCondition(field, "IS NULL", nil); err != nil {
+		m.lastErr = err
+	}
+	return m
+}
+
+// IsNotNull IsNotNull("name") => `name` IS NOT NULL
+func (m *Model) IsNotNull(field string) *Model {
+	if err := m.builder.WhereCondition(field, "IS NOT NULL", nil); err != nil {
+		m.lastErr = err
+	}
+	return m
+}
+
+// Where Where("age", ">", 1) => `age` > 1
+func (m *Model) Where(field string, condition string, value interface{}) *Model {
+	if err := m.builder.WhereCondition(field, condition, value); err != nil {
+		m.lastErr = err
+	}
+	return m
+}
+
+// OrWhere OrWhere("age", ">", 1) => OR `age` > 1
+func (m *Model) OrWhere(field string, condition string, value interface{}) *Model {
+	if err := m.builder.OrWhereCondition(field, condition, value); err != nil {
+		m.lastErr = err
+	}
+	return m
+}
+
+// WhereRaw WhereRaw("DATE(created_at) = ? AND score > ?", d, s) => (DATE(created_at) = ? AND score > ?)
+func (m *Model) WhereRaw(sql string, bindings ...interface{}) *Model {
+	m.builder.WhereRaw(sql, bindings...)
+	return m
+}
+
+// OrWhereRaw OrWhereRaw("score > ?", s) => OR (score > ?)
+func (m *Model) OrWhereRaw(sql string, bindings ...interface{}) *Model {
+	m.builder.OrWhereRaw(sql, bindings...)
+	return m
+}
+
+// WhereExists WhereExists(edb.Sub(orders.WhereRaw("`order`.`user_id` = `user`.`id`"))) => EXISTS (SELECT * FROM `order` WHERE ...)
+func (m *Model) WhereExists(sub *SubQuery) *Model {
+	m.builder.WhereExists(sub)
+	return m
+}
+
+// WhereNotExists WhereNotExists(sub) => NOT EXISTS (SELECT ...)
+func (m *Model) WhereNotExists(sub *SubQuery) *Model {
+	m.builder.WhereNotExists(sub)
+	return m
+}
+
+// OrWhereExists OrWhereExists(sub) => OR EXISTS (SELECT ...)
+func (m *Model) OrWhereExists(sub *SubQuery) *Model {
+	m.builder.OrWhereExists(sub)
+	return m
+}
+
+// WhereGroup the conditions of the closure in parentheses joined by AND
+//
+// Example usage:
+//
+// (
+// 	// WHERE (`status` = 1 OR `status` = 2) AND `age` > 18
+// 	m.WhereGroup(func(q *edb.Builder) {
+// 		q.WhereCondition("status", "=", 1)
+// 		q.OrWhereCondition("status", "=", 2)
+// 	}).Gt("age", 18).Get()
+// )
+func (m *Model) WhereGroup(closure func(q *Builder)) *Model {
+	m.builder.WhereGroup(closure)
+	return m
+}
+
+// OrWhereGroup the conditions of the closure in parentheses joined by OR
+func (m *Model) OrWhereGroup(closure func(q *Builder)) *Model {
+	m.builder.OrWhereGroup(closure)
+	return m
+}
+
+// Table query the table instead of the entity table, eg: Table("user AS u")
+func (m *Model) Table(table string) *Model {
+	m.builder.Table(table)
+	return m
+}
+
+// FromSub FromSub(edb.Sub(orders.GroupBy("user_id")), "t") => FROM (SELECT * FROM `order` GROUP BY `user_id`) AS `t`
+func (m *Model) FromSub(sub *SubQuery, alias string) *Model {
+	m.builder.FromSub(sub, alias)
+	return m
+}
+
+// With the common table expression of the sub model, the query can select from it by Table(name),
+// the sub model is reset
+func (m *Model) With(name string, sub *Model) *Model {
+	m.builder.With(name, Sub(sub))
+	return m
+}
+
+// WithRecursive the recursive common table expression, anchor UNION ALL recursive,
+// the recursive model joins the name
+//
+// Example usage:
+//
+// (
+// 	// WITH RECURSIVE `tree` AS (SELECT `id`, `parent_id`, `name` FROM `category` WHERE `id` = ?
+// 	// UNION ALL SELECT `c`.`id` AS `c__id`, ... FROM `category` AS `c` INNER JOIN `tree` ON `c`.`parent_id` = `tree`.`id`)
+// 	// SELECT * FROM `tree`
+// 	fields := []string{"id", "parent_id", "name"}
+// 	anchor, _ := edb.New(&Category{})
+// 	recursive, _ := edb.New(&Category{})
+// 	c, err := m.WithRecursive("tree", anchor.Select(fields).Eq("id", 1),
+// 		recursive.Table("category c").Join("tree", "c.parent_id", "=", "tree.id").Select([]string{"c.id", "c.parent_id", "c.name"})).
+// 		Table("tree").Get()
+// )
+func (m *Model) WithRecursive(name string, anchor *Model, recursive *Model) *Model {
+	m.builder.WithRecursive(name, Sub(anchor), Sub(recursive))
+	return m
+}
+
+// Union combine the select of the other model by UNION, the orders and the limit apply to the combined rows,
+// the other model is reset
+//
+// Example usage:
+//
+// (
+// 	active, _ := edb.New(&Order{})
+// 	archived, _ := edb.New(&ArchivedOrder{})
+// 	// SELECT * FROM (SELECT * FROM `order` WHERE `user_id` = ? UNION ALL SELECT * FROM `archived_order` WHERE `user_id` = ?) AS `u` ORDER BY ...
+// 	c, err := active.Eq("user_id", 1).UnionAll(archived.Eq("user_id", 1)).OrderByDesc("created_at").Paginate(1, 10)
+// )
+func (m *Model) Union(other *Model) *Model {
+	m.builder.Union(Sub(other))
+	return m
+}
+
+// UnionAll combine the select of the other model by UNION ALL
+func (m *Model) UnionAll(other *Model) *Model {
+	m.builder.UnionAll(Sub(other))
+	return m
+}
+
+// Join Join("profile", "profile.user_id", "=", "user.id") => INNER JOIN `profile` ON `profile`.`user_id` = `user`.`id`,
+// the qualified select fields are aliased table__column
+//
+// Example usage:
+//
+// (
+// 	type UserProfile struct {
+// 		UserId        int
+// 		UserName      string
+// 		ProfileAvatar string
+// 	}
+// 	m, _ := edb.New(&UserProfile{})
+// 	c, err := m.Table("user").Join("profile", "profile.user_id", "=", "user.id").
+// 		Select([]string{"user.id", "user.name", "profile.avatar"}).Get()
+// )
+func (m *Model) Join(table string, first string, operator string, second string) *Model {
+	m.builder.Join(table, first, operator, second)
+	return m
+}
+
+// LeftJoin LeftJoin("profile", "profile.user_id", "=", "user.id") => LEFT JOIN `profile` ON `profile`.`user_id` = `user`.`id`
+func (m *Model) LeftJoin(table string, first string, operator string, second string) *Model {
+	m.builder.LeftJoin(table, first, operator, second)
+	return m
+}
+
+// RightJoin RightJoin("profile", "profile.user_id", "=", "user.id") => RIGHT JOIN `profile` ON `profile`.`user_id` = `user`.`id`
+func (m *Model) RightJoin(table string, first string, operator string, second string) *Model {
+	m.builder.RightJoin(table, first, operator, second)
+	return m
+}
+
+// CrossJoin CrossJoin("tag") => CROSS JOIN `tag`
+func (m *Model) CrossJoin(table string) *Model {
+	m.builder.CrossJoin(table)
+	return m
+}
+
+// GroupBy GroupBy("name", "age") => GROUP BY `name`, `age`
+func (m *Model) GroupBy(fields ...string) *Model {
+	m.builder.GroupBy(fields...)
+	return m
+}
+
+// Having Having("total", ">", 1) => HAVING `total` > 1
+func (m *Model) Having(field string, condition string, value interface{}) *Model {
+	m.builder.HavingCondition(field, condition, value)
+	return m
+}
+
+// OrHaving OrHaving("total", ">", 1) => OR `total` > 1
+func (m *Model) OrHaving(field string, condition string, value interface{}) *Model {
+	m.builder.OrHavingCondition(field, condition, value)
+	return m
+}
+
+// HavingRaw HavingRaw("count(*) > ?", 1) => HAVING (count(*) > 1)
+func (m *Model) HavingRaw(sql string, bindings ...interface{}) *Model {
+	m.builder.HavingRaw(sql, bindings...)
+	return m
+}
+
+// ForUpdate SELECT ... FOR UPDATE, the model must be in a transaction
+//
+// Example usage:
+//
+// (
+// 	err := edb.Transaction(func(tx *edb.Tx) error {
+// 		m, _ := tx.New(&Job{})
+// 		job, err := m.Eq("status", 0).OrderBy("id").ForUpdate().SkipLocked().First()
+// 		...
+// 	})
+// )
+func (m *Model) ForUpdate() *Model {
+	m.builder.ForUpdate()
+	return m
+}
+
+// ForShare SELECT ... FOR SHARE, LOCK IN SHARE MODE of mysql, the model must be in a transaction
+func (m *Model) ForShare() *Model {
+	m.builder.ForShare()
+	return m
+}
+
+// SkipLocked SELECT ... FOR UPDATE SKIP LOCKED, skip the locked rows
+func (m *Model) SkipLocked() *Model {
+	m.builder.SkipLocked()
+	return m
+}
+
+// NoWait SELECT ... FOR UPDATE NOWAIT, fail instead of waiting for the locked rows
+func (m *Model) NoWait() *Model {
+	m.builder.NoWait()
+	return m
+}
+
 // OrderBy ASC sort
 func (m *Model) OrderBy(field string) *Model {
 	m.builder.OrderBy(field)
@@ -165,6 +562,19 @@ func (m *Model) OrderByDesc(field string) *Model {
 	return m
 }
 
+// OrderByRaw sort by the raw expression, eg: OrderByRaw("FIELD(status, ?, ?)", a, b)
+func (m *Model) OrderByRaw(sql string, bindings ...interface{}) *Model {
+	m.builder.OrderByRaw(sql, bindings...)
+	return m
+}
+
+// Set set the field when updating besides the update fields of the entity,
+// eg: Set("views", edb.Expr("`views` + 1"))
+func (m *Model) Set(field string, value interface{}) *Model {
+	m.builder.Set(field, value)
+	return m
+}
+
 // First get the first, if there is no where condition, the pk will be used as the query condition
 func (m *Model) First() (interface{}, error) {
 	defer m.reset()
@@ -176,7 +586,13 @@ func (m *Model) First() (interface{}, error) {
 		return nil, err
 	}
 	collect.originModel = m
-	return collect.Item(), nil
+	item := collect.Item()
+	//release the connection, the rest rows are not read
+	collect.Close()
+	if err := collect.Err(); err != nil {
+		return nil, err
+	}
+	return item, nil
 }
 
 // Get get all, return *Collect if there is no where condition, the pk will be used as the query condition，
@@ -206,25 +622,69 @@ func (m *Model) Paginate(page int64, pageSize int64) (collect *Collect, err erro
 
 	m.builder.limit = page
 	m.builder.limitOffset = pageSize
-	collect, err = m.returnCollect()
 
-	prepareSQL := m.stmt.PrepareSQL()
-	if i := strings.Index(prepareSQL, "FROM"); i >= 0 {
-		totalSQL := "SELECT count(*) as paginate " + string([]rune(prepareSQL)[i:])
-		sqlRows, err := m.Query(totalSQL, m.stmt.Bindings()...)
-		if err != nil {
-			return nil, err
-		}
-		if sqlRows.Next() {
-			c := 0
-			sqlRows.Scan(&c)
-			collect.paginateTotal = int64(c)
-		}
-		sqlRows.Close()
+	//count without the orders and the limit, before the rows are read,
+	//a transaction can not run a query while the rows of another are open
+	m.stmt.SetOp(OPCount)
+	if err = m.checkFinalErrWithRun(); err != nil {
+		return nil, err
+	}
+	sqlRows, release, err := m.query(m.stmt.PrepareSQL(), m.stmt.Bindings()...)
+	if err != nil {
+		return nil, err
+	}
+	var total int64
+	if sqlRows.Next() {
+		err = sqlRows.Scan(&total)
+	}
+	if err == nil {
+		err = sqlRows.Err()
+	}
+	sqlRows.Close()
+	release()
+	if err != nil {
+		return nil, err
+	}
+
+	m.stmt.reset()
+	m.stmt.SetOp(OPSelect)
+	collect, err = m.returnCollect()
+	if err != nil {
+		return nil, err
 	}
+	collect.paginateTotal = total
 	return
 }
 
+// Count count the rows of the where conditions, the groups if grouped
+func (m *Model) Count() (int64, error) {
+	var count sql.NullInt64
+	if err := m.aggregate("", "", &count); err != nil {
+		return 0, err
+	}
+	return count.Int64, nil
+}
+
+// Sum SUM of the field, 0 if no rows
+func (m *Model) Sum(field string) (float64, error) {
+	return m.aggregateFloat("SUM", field)
+}
+
+// Avg AVG of the field, 0 if no rows
+func (m *Model) Avg(field string) (float64, error) {
+	return m.aggregateFloat("AVG", field)
+}
+
+// Max MAX of the numeric field, 0 if no rows
+func (m *Model) Max(field string) (float64, error) {
+	return m.aggregateFloat("MAX", field)
+}
+
+// Min MIN of the numeric field, 0 if no rows
+func (m *Model) Min(field string) (float64, error) {
+	return m.aggregateFloat("MIN", field)
+}
+
 // Delete delete, if there is no where condition, the pk will be used as the query condition
 //
 // Example usage:
@@ -270,37 +730,149 @@ func (m *Model) Insert() (id int64, err error) {
 	return m.returnLastInsertId()
 }
 
-// Transaction todo
-// conn string connect key
-// closure exec closure
-// func (m *model) Transaction(conn string, closure func()) error {
-// 	return nil
-// }
+// Transaction run closure in a transaction, the model itself is bound to the
+// transaction until the closure returns, commit when the closure returns nil,
+// rollback when it returns an error or panics.
+// If the model is already bound to a transaction, a SAVEPOINT is used instead
+//
+// Example usage:
+//
+// (
+// 	err := m.Transaction(func(tx *edb.Tx) error {
+// 		if _, err := m.Insert(); err != nil {
+// 			return err
+// 		}
+// 		stock, err := tx.New(&Stock{Id: 1, Num: 9})
+// 		if err != nil {
+// 			return err
+// 		}
+// 		_, err = stock.Update([]string{"num"})
+// 		return err
+// 	})
+// )
+func (m *Model) Transaction(closure func(tx *Tx) error) error {
+	if m.tx != nil {
+		return m.tx.Transaction(closure)
+	}
+	return m.manager.transaction(m.context(), m.connectName, func(tx *Tx) error {
+		m.tx = tx
+		defer func() {
+			m.tx = nil
+		}()
+		return closure(tx)
+	})
+}
 
-// Query query and return *sql.Rows
+// Query query and return *sql.Rows, load-balanced across the replicas,
+// Shutdown waits for the query but not for reading the rows, Get and QueryCollect wait for both
 func (m *Model) Query(query string, args ...interface{}) (*sql.Rows, error) {
-	return manager.Query(query, args...)
+	sqlRows, release, err := m.query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	release()
+	return sqlRows, nil
 }
 
-// Exec exec and return sql.Resqult
+// query query on the reader, call release after the rows are closed
+func (m *Model) query(query string, args ...interface{}) (*sql.Rows, func(), error) {
+	e, err := m.reader()
+	if err != nil {
+		return nil, nil, err
+	}
+	return e.queryContext(m.context(), query, args...)
+}
+
+// Exec exec on the primary and return sql.Resqult
 func (m *Model) Exec(query string, args ...interface{}) (sql.Result, error) {
-	return manager.Exec(query, args...)
+	if result, ok, err := m.manager.dryRun(m.connectName, query, args); ok {
+		return result, err
+	}
+	e, err := m.writer()
+	if err != nil {
+		return nil, err
+	}
+	return e.ExecContext(m.context(), query, args...)
 }
 
 // QueryCollect query and return *Collect
 func (m *Model) QueryCollect(query string, args ...interface{}) (collect *Collect, err error) {
-	collect, err = manager.QueryCollect(query, args...)
+	sqlRows, release, err := m.query(query, args...)
 	if err != nil {
 		return nil, err
 	}
-	collect.originModel = m
-	return
+	return &Collect{
+		sqlRows:     sqlRows,
+		originModel: m,
+		release:     release,
+	}, nil
+}
+
+// ToSQL the select sql and bindings of the builder, the query is not executed and the builder is kept
+//
+// Example usage:
+//
+// (
+// 	sql, bindings, err := m.Eq("name", "tom").ToSQL()
+// 	// SELECT * FROM `user` WHERE `name` = ? ; [tom]
+// )
+func (m *Model) ToSQL() (string, []interface{}, error) {
+	return m.toSQL(false)
+}
+
+// ToRawSQL the select sql of the builder with the bindings interpolated, quoted and escaped,
+// for logging and debugging, do not execute it
+func (m *Model) ToRawSQL() (string, error) {
+	sql, _, err := m.toSQL(true)
+	return sql, err
 }
 
-// ToSQL todo return sql string
-// func (m *Model) ToSQL() string {
-// 	return ""
-// }
+func (m *Model) toSQL(raw bool) (string, []interface{}, error) {
+	if m.lastErr != nil {
+		return "", nil, m.lastErr
+	}
+	defer m.stmt.reset()
+
+	m.stmt.SetOp(OPSelect)
+	m.stmt.interpolate(raw)
+	if err := m.stmt.Build(); err != nil {
+		return "", nil, err
+	}
+	return m.stmt.PrepareSQL(), m.stmt.Bindings(), nil
+}
+
+func (m *Model) aggregateFloat(function string, field string) (float64, error) {
+	var value sql.NullFloat64
+	if err := m.aggregate(function, field, &value); err != nil {
+		return 0, err
+	}
+	return value.Float64, nil
+}
+
+// aggregate query the aggregate of the where conditions, count(*) if the function is empty
+func (m *Model) aggregate(function string, field string, dest interface{}) error {
+	defer m.reset()
+
+	if function != "" {
+		m.builder.Aggregate(function, field)
+	}
+	m.stmt.SetOp(OPCount)
+	if err := m.checkFinalErrWithRun(); err != nil {
+		return err
+	}
+	sqlRows, release, err := m.query(m.stmt.PrepareSQL(), m.stmt.Bindings()...)
+	if err != nil {
+		return err
+	}
+	defer release()
+	defer sqlRows.Close()
+	if sqlRows.Next() {
+		if err := sqlRows.Scan(dest); err != nil {
+			return err
+		}
+	}
+	return sqlRows.Err()
+}
 
 func (m *Model) returnRowAffected() (int64, error) {
 	sqlResult, err := m.execSQL()
@@ -312,6 +884,14 @@ func (m *Model) returnRowAffected() (int64, error) {
 }
 
 func (m *Model) returnLastInsertId() (int64, error) {
+	switch m.stmt.insertID() {
+	case InsertIDReturning, InsertIDOutput:
+		return m.returningSQL()
+	case InsertIDNone:
+		_, err := m.execSQL()
+		return 0, err
+	}
+
 	sqlResult, err := m.execSQL()
 	if err != nil {
 		return 0, err
@@ -320,6 +900,34 @@ func (m *Model) returnLastInsertId() (int64, error) {
 	return sqlResult.LastInsertId()
 }
 
+// returningSQL run the insert on the primary, the sql returns the pk as a row
+func (m *Model) returningSQL() (int64, error) {
+	if err := m.checkFinalErrWithRun(); err != nil {
+		return 0, err
+	}
+	if _, ok, err := m.manager.dryRun(m.connectName, m.stmt.PrepareSQL(), m.stmt.Bindings()); ok {
+		return 0, err
+	}
+	e, err := m.writer()
+	if err != nil {
+		return 0, err
+	}
+	sqlRows, release, err := e.queryContext(m.context(), m.stmt.PrepareSQL(), m.stmt.Bindings()...)
+	if err != nil {
+		return 0, err
+	}
+	defer release()
+	defer sqlRows.Close()
+
+	var id int64
+	if sqlRows.Next() {
+		if err := sqlRows.Scan(&id); err != nil {
+			return 0, err
+		}
+	}
+	return id, sqlRows.Err()
+}
+
 func (m *Model) returnCollect() (collect *Collect, err error) {
 	collect, err = m.querySQL()
 	if err != nil {
@@ -333,6 +941,9 @@ func (m *Model) checkFinalErrWithRun() error {
 	if m.lastErr != nil {
 		return m.lastErr
 	}
+	if m.builder.locked() && m.tx == nil {
+		return errors.New("edb Model.ForUpdate err: row locking requires a transaction, use the model of Tx.New")
+	}
 
 	if err := m.stmt.Build(); err != nil {
 		return err
@@ -345,7 +956,7 @@ func (m *Model) execSQL() (sql.Result, error) {
 	if err := m.checkFinalErrWithRun(); err != nil {
 		return nil, err
 	}
-	return manager.Exec(m.stmt.PrepareSQL(), m.stmt.Bindings()...)
+	return m.Exec(m.stmt.PrepareSQL(), m.stmt.Bindings()...)
 }
 
 func (m *Model) querySQL() (*Collect, error) {
@@ -353,7 +964,48 @@ func (m *Model) querySQL() (*Collect, error) {
 	if err := m.checkFinalErrWithRun(); err != nil {
 		return nil, err
 	}
-	return manager.QueryCollect(m.stmt.PrepareSQL(), m.stmt.Bindings()...)
+	return m.QueryCollect(m.stmt.PrepareSQL(), m.stmt.Bindings()...)
+}
+
+// context the context set by WithContext, or the context of the transaction
+func (m *Model) context() context.Context {
+	if m.ctx != nil {
+		return m.ctx
+	}
+	if m.tx != nil {
+		return m.tx.ctx
+	}
+	return context.Background()
+}
+
+// writer the executor to write to, the transaction the model is bound to,
+// otherwise the primary, recorded in the sticky scope of the context
+func (m *Model) writer() (executor, error) {
+	if m.tx != nil {
+		return m.tx, nil
+	}
+	p, err := m.manager.connect.pool(m.connectName)
+	if err != nil {
+		return nil, err
+	}
+	p.wrote(m.context())
+	return p, nil
+}
+
+// reader the executor to read from, the transaction the model is bound to,
+// the primary when UsePrimary or written in the sticky scope of the context, otherwise a replica
+func (m *Model) reader() (executor, error) {
+	if m.tx != nil {
+		return m.tx, nil
+	}
+	p, err := m.manager.connect.pool(m.connectName)
+	if err != nil {
+		return nil, err
+	}
+	if m.usePrimary {
+		return p, nil
+	}
+	return p.readerContext(m.context()), nil
 }
 
 ////struct tag
@@ -424,6 +1076,7 @@ func (m *Model) checkEntity() error {
 func (m *Model) reset() {
 	m.builder.reset()
 	m.stmt.reset()
+	m.usePrimary = false
 }
 
 func camelToUnerline(s string) string {
@@ -1,12 +1,15 @@
 package edb
 
 import (
+	"context"
+	"errors"
+	"path/filepath"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	//_ test
-	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/mattn/go-sqlite3"
 )
 
 //naming rules:
@@ -32,7 +35,7 @@ import (
 // }
 
 func TestModelNew(t *testing.T) {
-	TestBoot(t)
+	testBoot(t)
 
 	type User struct {
 		Id        int `type:"autoPk"`
@@ -99,7 +102,7 @@ func TestModelNew(t *testing.T) {
 }
 
 func TestModelInsert(t *testing.T) {
-	TestBoot(t)
+	testBoot(t)
 
 	type User struct {
 		Id        int `type:"autoPk"`
@@ -118,7 +121,7 @@ func TestModelInsert(t *testing.T) {
 	m, err := New(u)
 	assert.Nil(t, err)
 
-	m.Exec("truncate `user`;")
+	m.Exec("DELETE FROM `user`;")
 
 	id, err := m.Insert()
 	assert.Nil(t, err)
@@ -126,7 +129,7 @@ func TestModelInsert(t *testing.T) {
 }
 
 func TestModelFirst(t *testing.T) {
-	TestBoot(t)
+	testBoot(t)
 
 	type User struct {
 		Id        int `type:"autoPk"`
@@ -140,7 +143,7 @@ func TestModelFirst(t *testing.T) {
 	m1, err := New(u1)
 	assert.Nil(t, err)
 
-	m1.Exec("truncate `user`;")
+	m1.Exec("DELETE FROM `user`;")
 
 	entity, err := m1.First()
 	assert.Nil(t, err)
@@ -164,7 +167,7 @@ func TestModelFirst(t *testing.T) {
 }
 
 func TestModelGet(t *testing.T) {
-	TestBoot(t)
+	testBoot(t)
 
 	type User struct {
 		Id        int `type:"autoPk"`
@@ -178,7 +181,7 @@ func TestModelGet(t *testing.T) {
 	m1, err := New(u1)
 	assert.Nil(t, err)
 
-	m1.Exec("truncate `user`;")
+	m1.Exec("DELETE FROM `user`;")
 
 	collect, err := m1.Get()
 	assert.Nil(t, err)
@@ -216,7 +219,7 @@ func TestModelGet(t *testing.T) {
 
 func TestModelUpdate(t *testing.T) {
 
-	TestBoot(t)
+	testBoot(t)
 
 	type User struct {
 		Id        int `type:"autoPk"`
@@ -233,7 +236,7 @@ func TestModelUpdate(t *testing.T) {
 	m1, err := New(u1)
 	assert.Nil(t, err)
 
-	m1.Exec("truncate `user`;")
+	m1.Exec("DELETE FROM `user`;")
 	id, err := m1.Insert()
 	assert.Nil(t, err)
 	assert.Equal(t, int64(1), id)
@@ -244,7 +247,7 @@ func TestModelUpdate(t *testing.T) {
 		Age:  333,
 	})
 	_, err2 := m2.Update([]string{})
-	assert.EqualError(t, err2, "edb StmtMysql.Build err: OPUpdate no updated fields")
+	assert.EqualError(t, err2, "edb StmtSqlite.Build err: OPUpdate no updated fields")
 	//default  pk    => where `id` = 1
 	rowAffected, err := m2.Update([]string{"name", "age"})
 	assert.Equal(t, int64(1), rowAffected)
@@ -268,7 +271,7 @@ func TestModelUpdate(t *testing.T) {
 
 func TestModelDelete(t *testing.T) {
 
-	TestBoot(t)
+	testBoot(t)
 	type User struct {
 		Id        int `type:"autoPk"`
 		Name      string
@@ -280,7 +283,7 @@ func TestModelDelete(t *testing.T) {
 		Id: 1,
 	})
 	assert.Nil(t, err)
-	m1.Exec("truncate `user`;")
+	m1.Exec("DELETE FROM `user`;")
 
 	m2, err := New(&User{
 		Name: "ttt",
@@ -308,7 +311,7 @@ func TestModelDelete(t *testing.T) {
 }
 
 func TestModelPaginate(t *testing.T) {
-	TestBoot(t)
+	testBoot(t)
 	type User struct {
 		Id        int `type:"autoPk"`
 		Name      string
@@ -319,7 +322,7 @@ func TestModelPaginate(t *testing.T) {
 
 	m1, err := New(&User{})
 	assert.Nil(t, err)
-	m1.Exec("truncate `user`;")
+	m1.Exec("DELETE FROM `user`;")
 
 	for i := 0; i < 28; i++ {
 		m, err := New(&User{
@@ -349,3 +352,592 @@ func TestModelPaginate(t *testing.T) {
 	assert.Equal(t, 8, pageCount2)
 
 }
+
+func TestModelOn(t *testing.T) {
+	testBoot(t)
+
+	type User struct {
+		Id        int `type:"autoPk"`
+		Name      string
+		Age       int
+		CreatedAt time.Time `type:"dateTime"`
+		UpdatedAt time.Time `type:"dateTime"`
+	}
+
+	AddConfig("reporting", &Config{Driver: "sqlite3", Database: ":memory:"})
+	//the default manager is shared by the tests
+	t.Cleanup(func() {
+		conn := manager.connect
+		conn.mu.Lock()
+		defer conn.mu.Unlock()
+		if p, ok := conn.pools["reporting"]; ok {
+			p.close()
+		}
+		delete(conn.pools, "reporting")
+		delete(conn.config, "reporting")
+	})
+
+	m, err := New(&User{Name: "tom"})
+	assert.Nil(t, err)
+	m.Exec("DELETE FROM `user`;")
+
+	//not booted
+	_, err = m.On("reporting").Insert()
+	assert.EqualError(t, err, "edb: connect.pool err: reporting : database is not connected")
+
+	Boot("reporting")
+	_, err = Conn("reporting").Exec("CREATE TABLE `user` (`id` INTEGER PRIMARY KEY, `name` varchar(50) DEFAULT '', `age` int DEFAULT 0, `created_at` datetime DEFAULT NULL, `updated_at` datetime DEFAULT NULL);")
+	assert.Nil(t, err)
+	id, err := m.On("reporting").Insert()
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), id)
+
+	m2, err := Conn("reporting").New(&User{})
+	assert.Nil(t, err)
+	e, err := m2.Eq("name", "tom").First()
+	assert.Nil(t, err)
+	assert.Equal(t, "tom", e.(*User).Name)
+
+	//the default connection is another database
+	m3, err := New(&User{})
+	assert.Nil(t, err)
+	count, err := m3.Count()
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), count)
+
+	//unknown connection
+	_, err = m.On("other").First()
+	assert.EqualError(t, err, "edb Model.On err: other : database connection configuration dose not exist")
+}
+
+func TestModelWithContext(t *testing.T) {
+	testBoot(t)
+
+	type User struct {
+		Id        int `type:"autoPk"`
+		Name      string
+		Age       int
+		CreatedAt time.Time `type:"dateTime"`
+		UpdatedAt time.Time `type:"dateTime"`
+	}
+
+	m, err := New(&User{Name: "tom"})
+	assert.Nil(t, err)
+	m.Exec("DELETE FROM `user`;")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	id, err := m.WithContext(ctx).Insert()
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), id)
+
+	cancel()
+	_, err = m.Get()
+	assert.ErrorIs(t, err, context.Canceled)
+	_, err = m.Paginate(1, 10)
+	assert.ErrorIs(t, err, context.Canceled)
+	_, err = m.Insert()
+	assert.ErrorIs(t, err, context.Canceled)
+
+	//the rows are read until the deadline interrupts them
+	endless := "`id` < (WITH RECURSIVE c(x) AS (SELECT 1 UNION ALL SELECT x + 1 FROM c) SELECT count(*) FROM c)"
+	ctx2, cancel2 := context.WithTimeout(context.Background(), time.Millisecond*50)
+	defer cancel2()
+	c, err := m.WithContext(ctx2).WhereRaw(endless).Get()
+	assert.Nil(t, err)
+	for c.Next() {
+	}
+	assert.ErrorIs(t, c.Err(), context.DeadlineExceeded)
+
+	ctx3, cancel3 := context.WithTimeout(context.Background(), time.Millisecond*50)
+	defer cancel3()
+	e, err := m.WithContext(ctx3).WhereRaw(endless).First()
+	assert.Nil(t, e)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	err = TransactionContext(ctx, func(tx *Tx) error {
+		return nil
+	})
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestModelTimeTags(t *testing.T) {
+	testBoot(t)
+
+	type Event struct {
+		Id        int       `type:"autoPk"`
+		Day       time.Time `type:"date"`
+		At        time.Time `type:"time"`
+		CreatedAt time.Time `type:"dateTime"`
+	}
+
+	_, err := manager.Exec("CREATE TABLE IF NOT EXISTS `event` (`id` INTEGER PRIMARY KEY, `day` date, `at` time, `created_at` datetime);")
+	assert.Nil(t, err)
+
+	tt, _ := time.ParseInLocation(FTimeDateTime, "2021-08-09 16:22:22", time.Local)
+	m, err := New(&Event{Day: tt, At: tt, CreatedAt: tt})
+	assert.Nil(t, err)
+	id, err := m.Insert()
+	assert.Nil(t, err)
+
+	m2, err := New(&Event{})
+	assert.Nil(t, err)
+	e, err := m2.Eq("id", id).First()
+	assert.Nil(t, err)
+	assert.Equal(t, "2021-08-09", e.(*Event).Day.Format(FTimeDate))
+	assert.Equal(t, "16:22:22", e.(*Event).At.Format(FTimeTime))
+	assert.True(t, tt.Equal(e.(*Event).CreatedAt))
+
+	//NULL
+	_, err = manager.Exec("INSERT INTO `event` (`day`) VALUES (NULL);")
+	assert.Nil(t, err)
+	e2, err := m2.Eq("id", id+1).First()
+	assert.Nil(t, err)
+	assert.True(t, e2.(*Event).CreatedAt.IsZero())
+}
+
+func TestModelWhereGroup(t *testing.T) {
+	testBoot(t)
+
+	type User struct {
+		Id   int `type:"autoPk"`
+		Name string
+		Age  int
+	}
+
+	testSeed(t, "user", &User{Name: "tom", Age: 10}, &User{Name: "jerry", Age: 20}, &User{Name: "spike", Age: 30}, &User{Name: "tom", Age: 40})
+	m, err := New(&User{})
+	assert.Nil(t, err)
+	rows := testRows{t}
+
+	// (name = tom OR name = jerry) AND age > 15
+	assert.Equal(t, []string{"jerry", "tom"}, rows.names(m.WhereGroup(func(q *Builder) {
+		q.WhereCondition("name", "=", "tom")
+		q.OrWhereCondition("name", "=", "jerry")
+	}).Gt("age", 15).OrderBy("id").Get()))
+
+	// age < 15 OR (name = spike AND age = 30)
+	assert.Equal(t, []string{"tom", "spike"}, rows.names(m.Lt("age", 15).OrWhereGroup(func(q *Builder) {
+		q.WhereCondition("name", "=", "spike")
+		q.WhereCondition("age", "=", 30)
+	}).OrderBy("id").Get()))
+
+	assert.Equal(t, []string{"jerry", "spike"}, rows.names(m.Eq("age", 20).OrEq("name", "spike").OrderBy("id").Get()))
+	assert.Equal(t, []string{"tom", "tom"}, rows.names(m.Where("age", "<", 15).OrWhere("age", ">", 35).Get()))
+}
+
+func TestModelIn(t *testing.T) {
+	testBoot(t)
+
+	type User struct {
+		Id        int `type:"autoPk"`
+		Name      string
+		Age       int
+		CreatedAt time.Time `type:"dateTime"`
+	}
+
+	testSeed(t, "user", &User{Name: "tom", Age: 10}, &User{Name: "jerry", Age: 20}, &User{Name: "spike", Age: 30})
+	m, err := New(&User{})
+	assert.Nil(t, err)
+	m.Exec("UPDATE `user` SET `created_at` = NULL WHERE `age` = 30;")
+	rows := testRows{t}
+
+	assert.Equal(t, []int{10, 30}, rows.ages(m.In("age", []int{10, 30, 40}).OrderBy("id").Get()))
+	assert.Equal(t, []int{}, rows.ages(m.In("age", []int{}).Get()))
+	assert.Equal(t, []int{20}, rows.ages(m.NotIn("age", []int{10, 30}).Get()))
+	assert.Equal(t, []int{10, 20, 30}, rows.ages(m.NotIn("age", []int{}).OrderBy("id").Get()))
+	assert.Equal(t, []int{20, 30}, rows.ages(m.Between("age", 15, 30).OrderBy("id").Get()))
+	assert.Equal(t, []int{10}, rows.ages(m.NotBetween("age", 15, 30).Get()))
+	assert.Equal(t, []int{30}, rows.ages(m.IsNull("created_at").Get()))
+	assert.Equal(t, []int{10, 20}, rows.ages(m.IsNotNull("created_at").OrderBy("id").Get()))
+	assert.Equal(t, []int{20, 30}, rows.ages(m.NotLike("name", "t%").OrderBy("id").Get()))
+}
+
+func TestModelRaw(t *testing.T) {
+	testBoot(t)
+
+	type User struct {
+		Id   int `type:"autoPk"`
+		Name string
+		Age  int
+	}
+
+	testSeed(t, "user", &User{Name: "tom", Age: 10}, &User{Name: "jerry", Age: 20}, &User{Name: "spike", Age: 30})
+	m, err := New(&User{})
+	assert.Nil(t, err)
+	rows := testRows{t}
+
+	assert.Equal(t, []string{"jerry", "spike"}, rows.names(m.WhereRaw("upper(`name`) != ? AND `age` > ?", "TOM", 5).OrderBy("id").Get()))
+	assert.Equal(t, []string{"jerry", "tom", "spike"}, rows.names(m.OrderByRaw("`name` = ? DESC", "jerry").OrderBy("id").Get()))
+
+	//the raw select maps to the entity by the alias
+	item, err := m.Select([]string{"id"}).SelectRaw("`age` * ? AS `age`", 2).Eq("name", "spike").First()
+	assert.Nil(t, err)
+	assert.Equal(t, 60, item.(*User).Age)
+
+	rowAffected, err := m.Set("age", Expr("`age` + ?", 1)).Gte("age", 20).Update(nil)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), rowAffected)
+	item, err = m.Eq("name", "spike").First()
+	assert.Nil(t, err)
+	assert.Equal(t, 31, item.(*User).Age)
+}
+
+func TestModelJoin(t *testing.T) {
+	testBoot(t)
+
+	type User struct {
+		Id   int `type:"autoPk"`
+		Name string
+		Age  int
+	}
+	type Profile struct {
+		Id     int `type:"autoPk"`
+		UserId int
+		Avatar string
+	}
+	type UserProfile struct {
+		UserId        int
+		UserName      string
+		ProfileAvatar string
+	}
+
+	m, err := New(&User{})
+	assert.Nil(t, err)
+	_, err = m.Exec("CREATE TABLE IF NOT EXISTS `profile` (`id` INTEGER PRIMARY KEY, `user_id` int DEFAULT 0, `avatar` varchar(50) DEFAULT '');")
+	assert.Nil(t, err)
+	testSeed(t, "user", &User{Name: "tom", Age: 10}, &User{Name: "jerry", Age: 20})
+	testSeed(t, "profile", &Profile{UserId: 2, Avatar: "jerry.png"})
+	rows := testRows{t}
+
+	//flat result struct
+	m2, err := New(&UserProfile{})
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{&UserProfile{UserId: 2, UserName: "jerry", ProfileAvatar: "jerry.png"}},
+		rows.items(m2.Table("user").Join("profile", "profile.user_id", "=", "user.id").
+			Select([]string{"user.id", "user.name", "profile.avatar"}).Get()))
+
+	//the entity, columns of the entity table map to its fields
+	assert.Equal(t, []interface{}{&User{Id: 1, Name: "tom"}},
+		rows.items(m.LeftJoin("profile AS p", "p.user_id", "=", "user.id").
+			Select([]string{"user.id", "user.name"}).IsNull("p.id").OrderBy("user.id").Get()))
+
+	collect, err := m.Join("profile", "profile.user_id", "=", "user.id").Paginate(1, 10)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), collect.Total())
+}
+
+func TestModelAggregate(t *testing.T) {
+	testBoot(t)
+
+	type User struct {
+		Id   int `type:"autoPk"`
+		Name string
+		Age  int
+	}
+	type UserCount struct {
+		Name  string
+		Total int
+	}
+
+	testSeed(t, "user")
+	m, err := New(&User{})
+	assert.Nil(t, err)
+
+	count, err := m.Count()
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), count)
+	sum, err := m.Sum("age")
+	assert.Nil(t, err)
+	assert.Equal(t, float64(0), sum)
+
+	testSeed(t, "user", &User{Name: "tom", Age: 10}, &User{Name: "tom", Age: 20}, &User{Name: "jerry", Age: 30}, &User{Name: "spike", Age: 45})
+
+	count, err = m.Gt("age", 10).Count()
+	assert.Nil(t, err)
+	assert.Equal(t, int64(3), count)
+	sum, err = m.Sum("age")
+	assert.Nil(t, err)
+	assert.Equal(t, float64(105), sum)
+	avg, err := m.Eq("name", "tom").Avg("age")
+	assert.Nil(t, err)
+	assert.Equal(t, float64(15), avg)
+	max, err := m.Max("age")
+	assert.Nil(t, err)
+	assert.Equal(t, float64(45), max)
+	min, err := m.Min("age")
+	assert.Nil(t, err)
+	assert.Equal(t, float64(10), min)
+
+	//groups
+	count, err = m.GroupBy("name").Count()
+	assert.Nil(t, err)
+	assert.Equal(t, int64(3), count)
+
+	m2, err := New(&UserCount{})
+	assert.Nil(t, err)
+	collect, err := m2.Table("user").Select([]string{"name"}).SelectRaw("count(*) AS total").
+		GroupBy("name").HavingRaw("count(*) >= ?", 1).OrderByDesc("total").OrderBy("name").Paginate(1, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(3), collect.Total())
+	assert.Equal(t, []interface{}{&UserCount{Name: "tom", Total: 2}, &UserCount{Name: "jerry", Total: 1}}, testRows{t}.items(collect, err))
+
+	collect, err = m2.Table("user").Select([]string{"name"}).SelectRaw("count(*) AS total").
+		GroupBy("name").Having("total", ">", 1).Paginate(1, 10)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), collect.Total())
+}
+
+func TestModelSub(t *testing.T) {
+	testBoot(t)
+
+	type User struct {
+		Id   int `type:"autoPk"`
+		Name string
+		Age  int
+	}
+	type Order struct {
+		Id     int `type:"autoPk"`
+		UserId int
+		Amount int
+	}
+
+	m, err := New(&User{})
+	assert.Nil(t, err)
+	_, err = m.Exec("CREATE TABLE IF NOT EXISTS `order` (`id` INTEGER PRIMARY KEY, `user_id` int DEFAULT 0, `amount` int DEFAULT 0);")
+	assert.Nil(t, err)
+	testSeed(t, "user", &User{Name: "tom", Age: 10}, &User{Name: "jerry", Age: 20}, &User{Name: "spike", Age: 30})
+	testSeed(t, "order", &Order{UserId: 1, Amount: 50}, &Order{UserId: 2, Amount: 150}, &Order{UserId: 2, Amount: 80}, &Order{UserId: 3, Amount: 300})
+	orders, err := New(&Order{})
+	assert.Nil(t, err)
+	rows := testRows{t}
+
+	assert.Equal(t, []string{"jerry"}, rows.names(m.Lt("age", 25).In("id", Sub(orders.Select([]string{"user_id"}).Gt("amount", 100))).Get()))
+	assert.Equal(t, []string{"tom", "jerry"}, rows.names(m.WhereExists(Sub(orders.WhereRaw("`order`.`user_id` = `user`.`id`").Lt("amount", 100))).OrderBy("id").Get()))
+	assert.Equal(t, []string{"spike"}, rows.names(m.WhereNotExists(Sub(orders.WhereRaw("`order`.`user_id` = `user`.`id`").Lt("amount", 100))).Get()))
+
+	//the orders model is reset by Sub
+	count, err := orders.Count()
+	assert.Nil(t, err)
+	assert.Equal(t, int64(4), count)
+
+	type UserTotal struct {
+		UserId int
+		Total  int
+	}
+	m2, err := New(&UserTotal{})
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{&UserTotal{UserId: 3, Total: 300}, &UserTotal{UserId: 2, Total: 230}},
+		rows.items(m2.FromSub(Sub(orders.Select([]string{"user_id"}).SelectRaw("sum(`amount`) AS total").GroupBy("user_id")), "t").
+			Gt("t.total", 100).OrderByDesc("t.total").Get()))
+}
+
+func TestModelLock(t *testing.T) {
+	testBoot(t)
+
+	type User struct {
+		Id   int `type:"autoPk"`
+		Name string
+	}
+
+	m, err := New(&User{})
+	assert.Nil(t, err)
+	_, err = m.ForUpdate().First()
+	assert.EqualError(t, err, "edb Model.ForUpdate err: row locking requires a transaction, use the model of Tx.New")
+	_, err = m.SkipLocked().Get()
+	assert.NotNil(t, err)
+
+	//the model is reset after the error
+	_, err = m.Get()
+	assert.Nil(t, err)
+
+	err = Transaction(func(tx *Tx) error {
+		m, err := tx.New(&User{})
+		assert.Nil(t, err)
+		_, err = m.ForUpdate().Get()
+		return err
+	})
+	assert.EqualError(t, err, "edb StmtSqlite.Build err: row locking is unsupported by the dialect")
+}
+
+func TestModelUnion(t *testing.T) {
+	testBoot(t)
+
+	type User struct {
+		Id   int `type:"autoPk"`
+		Name string
+		Age  int
+	}
+	type ArchivedUser struct {
+		Id   int `type:"autoPk"`
+		Name string
+		Age  int
+	}
+
+	m, err := New(&User{})
+	assert.Nil(t, err)
+	_, err = m.Exec("CREATE TABLE IF NOT EXISTS `archived_user` (`id` INTEGER PRIMARY KEY, `name` varchar(50) DEFAULT '', `age` int DEFAULT 0);")
+	assert.Nil(t, err)
+	testSeed(t, "user", &User{Name: "tom", Age: 10}, &User{Name: "jerry", Age: 20})
+	testSeed(t, "archived_user", &ArchivedUser{Id: 10, Name: "tom", Age: 10}, &ArchivedUser{Id: 11, Name: "spike", Age: 30})
+	archived, err := New(&ArchivedUser{})
+	assert.Nil(t, err)
+
+	fields := []string{"name", "age"}
+	collect, err := m.Select(fields).Gt("age", 5).Union(archived.Select(fields).Gt("age", 5)).OrderByDesc("age").Paginate(1, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(3), collect.Total())
+	assert.Equal(t, []interface{}{&User{Name: "spike", Age: 30}, &User{Name: "jerry", Age: 20}}, testRows{t}.items(collect, err))
+
+	count, err := m.Select(fields).UnionAll(archived.Select(fields)).Count()
+	assert.Nil(t, err)
+	assert.Equal(t, int64(4), count)
+}
+
+func TestModelWith(t *testing.T) {
+	testBoot(t)
+
+	type Category struct {
+		Id       int `type:"autoPk"`
+		ParentId int
+		Name     string
+	}
+
+	m, err := New(&Category{})
+	assert.Nil(t, err)
+	_, err = m.Exec("CREATE TABLE IF NOT EXISTS `category` (`id` INTEGER PRIMARY KEY, `parent_id` int DEFAULT 0, `name` varchar(50) DEFAULT '');")
+	assert.Nil(t, err)
+	testSeed(t, "category", &Category{Name: "root"}, &Category{ParentId: 1, Name: "a"}, &Category{ParentId: 2, Name: "a1"},
+		&Category{ParentId: 3, Name: "a11"}, &Category{ParentId: 1, Name: "b"}, &Category{Name: "other"})
+	rows := testRows{t}
+
+	fields := []string{"id", "parent_id", "name"}
+	anchor, err := New(&Category{})
+	assert.Nil(t, err)
+	recursive, err := New(&Category{})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"a", "a1", "a11"}, rows.names(m.WithRecursive("tree", anchor.Select(fields).Eq("id", 2),
+		recursive.Table("category c").Join("tree", "c.parent_id", "=", "tree.id").Select([]string{"c.id", "c.parent_id", "c.name"})).
+		Table("tree").OrderBy("id").Get()))
+
+	assert.Equal(t, []string{"b", "a"}, rows.names(m.With("children", anchor.Eq("parent_id", 1)).Table("children").OrderByDesc("id").Get()))
+
+	count, err := m.With("roots", anchor.Eq("parent_id", 0)).Table("roots").Count()
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), count)
+}
+
+func TestModelToSQL(t *testing.T) {
+	testBoot(t)
+
+	type User struct {
+		Id   int `type:"autoPk"`
+		Name string
+		Age  int
+	}
+
+	testSeed(t, "user", &User{Name: "o'neil", Age: 10})
+	m, err := New(&User{})
+	assert.Nil(t, err)
+
+	m.Eq("name", "o'neil").Gt("age", 5)
+	sql, bindings, err := m.ToSQL()
+	assert.Nil(t, err)
+	assert.Equal(t, "SELECT * FROM \"user\" WHERE \"name\" = ? AND \"age\" > ? ;", sql)
+	assert.Equal(t, []interface{}{"o'neil", 5}, bindings)
+
+	raw, err := m.ToRawSQL()
+	assert.Nil(t, err)
+	assert.Equal(t, "SELECT * FROM \"user\" WHERE \"name\" = 'o''neil' AND \"age\" > 5 ;", raw)
+
+	//the raw sql is valid
+	rows, err := m.Query(raw)
+	assert.Nil(t, err)
+	assert.True(t, rows.Next())
+	rows.Close()
+
+	//the builder is kept
+	item, err := m.First()
+	assert.Nil(t, err)
+	assert.Equal(t, "o'neil", item.(*User).Name)
+
+	m.lastErr = errors.New("edb test err")
+	_, _, err = m.ToSQL()
+	assert.EqualError(t, err, "edb test err")
+	m.lastErr = nil
+}
+
+func TestModelReplicaRouting(t *testing.T) {
+	type User struct {
+		Id        int `type:"autoPk"`
+		Name      string
+		Age       int
+		CreatedAt time.Time `type:"dateTime"`
+		UpdatedAt time.Time `type:"dateTime"`
+	}
+
+	dir := t.TempDir()
+	primary, replica := filepath.Join(dir, "primary.db"), filepath.Join(dir, "replica.db")
+	mgr := NewManager()
+	assert.True(t, mgr.AddConfig("default", &Config{Driver: "sqlite3", Database: primary, Sticky: true, Replicas: []*Config{{Database: replica}}}))
+	assert.True(t, mgr.AddConfig("replica", &Config{Driver: "sqlite3", Database: replica}))
+	assert.Nil(t, mgr.Open("default"))
+	assert.Nil(t, mgr.Open("replica"))
+	defer mgr.Close()
+
+	//the replica is not replicated, a row tells where a read went
+	for _, c := range []*Connection{mgr.Conn("default"), mgr.Conn("replica")} {
+		_, err := c.Exec("CREATE TABLE `user` (`id` INTEGER PRIMARY KEY, `name` varchar(50) DEFAULT '', `age` int DEFAULT 0, `created_at` datetime DEFAULT NULL, `updated_at` datetime DEFAULT NULL);")
+		assert.Nil(t, err)
+	}
+	_, err := mgr.Conn("replica").Exec("INSERT INTO `user` (`name`) VALUES ('replica');")
+	assert.Nil(t, err)
+	_, err = mgr.Exec("INSERT INTO `user` (`name`) VALUES ('primary');")
+	assert.Nil(t, err)
+
+	name := func(m *Model) string {
+		e, err := m.OrderBy("id").First()
+		assert.Nil(t, err)
+		return e.(*User).Name
+	}
+
+	//replica reads
+	m, err := mgr.New(&User{Name: "tom"})
+	assert.Nil(t, err)
+	assert.Equal(t, "replica", name(m))
+
+	//primary writes, without a sticky context the reads stay on the replica
+	_, err = m.Insert()
+	assert.Nil(t, err)
+	assert.Equal(t, "replica", name(m))
+	replicaCount, err := mgr.Conn("replica").New(&User{})
+	assert.Nil(t, err)
+	count, err := replicaCount.Count()
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), count)
+
+	//UsePrimary for one query
+	assert.Equal(t, "primary", name(m.UsePrimary()))
+	assert.Equal(t, "replica", name(m))
+
+	//Sticky in the scope of the context, other models of the request read the primary
+	ctx := StickyContext(context.Background())
+	m2, err := mgr.New(&User{})
+	assert.Nil(t, err)
+	assert.Equal(t, "replica", name(m2.WithContext(ctx)))
+	_, err = m.WithContext(ctx).Insert()
+	assert.Nil(t, err)
+	assert.Equal(t, "primary", name(m2.WithContext(ctx)))
+	rows, err := mgr.Conn("default").QueryContext(ctx, "SELECT `name` FROM `user` ORDER BY `id` LIMIT 1;")
+	assert.Nil(t, err)
+	assert.True(t, rows.Next())
+	var n string
+	assert.Nil(t, rows.Scan(&n))
+	rows.Close()
+	assert.Equal(t, "primary", n)
+
+	//another request is not sticky
+	m3, err := mgr.New(&User{})
+	assert.Nil(t, err)
+	assert.Equal(t, "replica", name(m3.WithContext(StickyContext(context.Background()))))
+	assert.Equal(t, "replica", name(m3))
+}
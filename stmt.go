@@ -0,0 +1,638 @@
+package edb
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type (
+
+	// stmt the sql building shared by the Stmt implementations,
+	// the database differences come from the dialect
+	stmt struct {
+		// name the Stmt name in errors
+		name       string
+		dialect    Dialect
+		builder    *Builder
+		prepareSQL string
+		bindings   []interface{}
+		op         operateType
+		// err the error of a subquery
+		err error
+		// raw interpolate the bindings, see ToRawSQL
+		raw bool
+	}
+)
+
+// newDialectStmt new the Stmt of the dialect, the built-in dialects keep their Stmt types
+func newDialectStmt(d Dialect) Stmt {
+	switch d.(type) {
+	case mysqlDialect:
+		return &StmtMysql{stmt{name: "StmtMysql", dialect: d, bindings: make([]interface{}, 0)}}
+	case postgresDialect:
+		return &StmtPostgres{stmt{name: "StmtPostgres", dialect: d, bindings: make([]interface{}, 0)}}
+	case sqliteDialect:
+		return &StmtSqlite{stmt{name: "StmtSqlite", dialect: d, bindings: make([]interface{}, 0)}}
+	case sqlserverDialect:
+		return &StmtSqlserver{stmt{name: "StmtSqlserver", dialect: d, bindings: make([]interface{}, 0)}}
+	}
+	return &stmt{name: "Stmt", dialect: d, bindings: make([]interface{}, 0)}
+}
+
+// SetBuilder SetBuilder
+func (s *stmt) SetBuilder(b *Builder) {
+	s.builder = b
+}
+
+// SetOp SetOp
+func (s *stmt) SetOp(op operateType) {
+	s.op = op
+}
+
+// Build build dql, and bind parameters
+func (s *stmt) Build() error {
+	q := s.dialect.Quote
+	sqlBuffer := new(strings.Builder)
+	switch s.op {
+	case OPSelect:
+		s.selectStr(sqlBuffer)
+	case OPCount:
+		s.countStr(sqlBuffer)
+	case OPUpdate:
+		if len(s.builder.updateFields) == 0 && len(s.builder.sets) == 0 {
+			return fmt.Errorf("edb %s.Build err: OPUpdate no updated fields", s.name)
+		}
+		sqlBuffer.WriteString(fmt.Sprintf("UPDATE %s SET ", q(s.table())))
+
+		updateStr := ""
+		for _, item := range s.builder.updateFields {
+			if f, ok := s.builder.model.entityFields[item]; ok {
+				if (f.fType == "time.Time" || f.fTagType == "Time") && f.value == nil {
+					continue
+				}
+				updateStr += "," + q(item) + " = " + s.bind(timeValue(f))
+			}
+		}
+		for _, item := range s.builder.sets {
+			updateStr += "," + q(item[0].(string)) + " = " + s.bind(item[1])
+		}
+		sqlBuffer.WriteString(strings.TrimLeft(updateStr, ",") + " ")
+
+		if len(s.builder.wheres) == 0 {
+			//use the pk as where condition
+			pk := s.builder.model.pkField
+			if pk == "" {
+				return fmt.Errorf("edb %s.Build err: OPUpdate the pirmary key cannot be fount", s.name)
+			}
+			sqlBuffer.WriteString(fmt.Sprintf("WHERE %s = %s ", q(pk), s.bind(s.builder.model.entityFields[pk].value)))
+		} else {
+			//builder.wheres
+			if ws := s.wheresStr(); ws != "" {
+				sqlBuffer.WriteString(ws)
+			}
+		}
+	case OPInsert:
+		sqlBuffer.WriteString("INSERT INTO " + q(s.table()) + " ")
+
+		fstr := ""
+		vstr := ""
+		for _, f := range s.builder.model.entityFields {
+			if f.isAuto {
+				continue
+			}
+			if (f.fType == "time.Time" || f.fTagType == "Time") && f.value == nil {
+				continue
+			}
+			fstr += "," + q(f.name)
+			vstr += "," + s.bind(timeValue(f))
+		}
+
+		switch s.insertID() {
+		case InsertIDReturning:
+			sqlBuffer.WriteString(fmt.Sprintf("(%s) VALUES (%s) %s", strings.TrimLeft(fstr, ","), strings.TrimLeft(vstr, ","), s.dialect.Returning(s.builder.model.pkField)))
+		case InsertIDOutput:
+			sqlBuffer.WriteString(fmt.Sprintf("(%s) %s VALUES (%s)", strings.TrimLeft(fstr, ","), s.dialect.Returning(s.builder.model.pkField), strings.TrimLeft(vstr, ",")))
+		default:
+			sqlBuffer.WriteString(fmt.Sprintf("(%s) VALUES (%s)", strings.TrimLeft(fstr, ","), strings.TrimLeft(vstr, ",")))
+		}
+
+	case OPDelete:
+		sqlBuffer.WriteString(fmt.Sprintf("DELETE FROM %s ", q(s.table())))
+		if len(s.builder.wheres) == 0 {
+			//use the pk as where condition
+			pk := s.builder.model.pkField
+			if pk != "" {
+				sqlBuffer.WriteString(fmt.Sprintf("WHERE %s = %s ", q(pk), s.bind(s.builder.model.entityFields[pk].value)))
+			}
+		} else {
+			//builder.wheres
+			if ws := s.wheresStr(); ws != "" {
+				sqlBuffer.WriteString(ws)
+			}
+		}
+	default:
+		return fmt.Errorf("edb %s.Build err: undefined OP type", s.name)
+	}
+	if s.err != nil {
+		return s.err
+	}
+	sqlBuffer.WriteString(";")
+	s.prepareSQL = sqlBuffer.String()
+	return nil
+}
+
+// selectStr the select with the orders and the limit
+func (s *stmt) selectStr(sqlBuffer *strings.Builder) {
+	s.withStr(sqlBuffer)
+	if len(s.builder.unions) > 0 {
+		s.queryStr(sqlBuffer, "* ")
+	} else {
+		s.queryStr(sqlBuffer, s.fieldsStr())
+	}
+	//builder.orders
+	if len(s.builder.orders) > 0 {
+		raws := s.builder.orderRaws
+		for i, item := range s.builder.orders {
+			order := ""
+			if item[0] == "" {
+				//raw order
+				order = s.rawStr(raws[0])
+				raws = raws[1:]
+			} else {
+				order = fmt.Sprintf("%s %s", s.quoteName(item[1]), item[0])
+			}
+			if i == 0 {
+				sqlBuffer.WriteString("ORDER BY " + order + " ")
+			} else {
+				sqlBuffer.WriteString(", " + order + " ")
+			}
+		}
+	}
+	//the limit clause requires an order
+	var ol OrderedLimiter
+	if dialectAs(s.dialect, &ol) && ol.LimitRequiresOrder() && s.builder.limit > 0 && len(s.builder.orders) == 0 {
+		if pk := s.builder.model.pkField; pk != "" && len(s.builder.groups) == 0 {
+			if len(s.builder.joins) > 0 {
+				pk = tableAlias(s.table()) + "." + pk
+			}
+			sqlBuffer.WriteString("ORDER BY " + s.quoteName(pk) + " ASC ")
+		} else {
+			sqlBuffer.WriteString("ORDER BY (SELECT NULL) ")
+		}
+	}
+	//limit
+	if s.builder.limit > 0 {
+		if s.builder.limitOffset == 0 {
+			sqlBuffer.WriteString(s.dialect.Limit(1, 0) + " ")
+		} else {
+			sqlBuffer.WriteString(s.dialect.Limit(s.builder.limitOffset, s.builder.limitOffset*(s.builder.limit-1)) + " ")
+		}
+	}
+	//lock
+	if s.builder.locked() {
+		var locker Locker
+		if !dialectAs(s.dialect, &locker) {
+			if s.err == nil {
+				s.err = fmt.Errorf("edb %s.Build err: row locking is unsupported by the dialect", s.name)
+			}
+			return
+		}
+		sqlBuffer.WriteString(locker.Lock(s.builder.lock == "SHARE", s.builder.lockOption) + " ")
+	}
+}
+
+// countStr count the rows of the where conditions, or the aggregate of the builder,
+// a grouped select is counted as a subquery
+func (s *stmt) countStr(sqlBuffer *strings.Builder) {
+	s.withStr(sqlBuffer)
+	if aggregate := s.builder.aggregate; len(aggregate) > 0 {
+		s.queryStr(sqlBuffer, fmt.Sprintf("%s(%s) AS aggregate ", aggregate[0], s.quoteName(aggregate[1])))
+		return
+	}
+	if len(s.builder.groups) > 0 && len(s.builder.unions) == 0 {
+		sqlBuffer.WriteString("SELECT count(*) AS paginate FROM (")
+		s.queryStr(sqlBuffer, s.fieldsStr())
+		sqlBuffer.WriteString(") AS " + s.dialect.Quote("paginate") + " ")
+		return
+	}
+	s.queryStr(sqlBuffer, "count(*) AS paginate ")
+}
+
+// withStr the common table expressions prefixed to the select
+func (s *stmt) withStr(sqlBuffer *strings.Builder) {
+	if len(s.builder.ctes) == 0 {
+		return
+	}
+	recursive := false
+	for _, c := range s.builder.ctes {
+		recursive = recursive || c.recursive != nil
+	}
+	with := "WITH"
+	var w Wither
+	if dialectAs(s.dialect, &w) {
+		with = w.With(recursive)
+	} else if recursive {
+		with = "WITH RECURSIVE"
+	}
+	ctes := make([]string, len(s.builder.ctes))
+	for i, c := range s.builder.ctes {
+		sql := s.subSelectStr(c.anchor)
+		if c.recursive != nil {
+			sql += " UNION ALL " + s.subSelectStr(c.recursive)
+		}
+		ctes[i] = s.dialect.Quote(c.name) + " AS (" + sql + ")"
+	}
+	sqlBuffer.WriteString(with + " " + strings.Join(ctes, ", ") + " ")
+}
+
+// fieldsStr the select fields, * if none
+func (s *stmt) fieldsStr() string {
+	fields := make([]string, 0, len(s.builder.fields)+len(s.builder.selectRaws))
+	for _, f := range s.builder.fields {
+		fields = append(fields, s.selectName(f))
+	}
+	for _, e := range s.builder.selectRaws {
+		fields = append(fields, s.rawStr(e))
+	}
+	if len(fields) == 0 {
+		return "* "
+	}
+	return strings.Join(fields, ", ") + " "
+}
+
+// queryStr SELECT fields FROM, the joins, the where conditions, GROUP BY and HAVING,
+// the fields of the unions are selected from the union as a subquery
+func (s *stmt) queryStr(sqlBuffer *strings.Builder, fields string) {
+	if len(s.builder.unions) > 0 {
+		sqlBuffer.WriteString("SELECT " + fields + "FROM (" + s.unionStr() + ") AS " + s.dialect.Quote("u") + " ")
+		return
+	}
+	sqlBuffer.WriteString("SELECT " + fields)
+	if s.builder.fromSub != nil {
+		sqlBuffer.WriteString("FROM " + s.bind(s.builder.fromSub) + " AS " + s.dialect.Quote(s.builder.tableName) + " ")
+	} else {
+		sqlBuffer.WriteString("FROM " + s.quoteName(s.table()) + " ")
+	}
+	//builder.joins
+	for _, join := range s.builder.joins {
+		sqlBuffer.WriteString(join[0] + " " + s.quoteName(join[1]) + " ")
+		if join[0] != "CROSS JOIN" {
+			sqlBuffer.WriteString(fmt.Sprintf("ON %s %s %s ", s.quoteName(join[2]), join[3], s.quoteName(join[4])))
+		}
+	}
+	//builder.wheres
+	if ws := s.wheresStr(); ws != "" {
+		sqlBuffer.WriteString(ws)
+	}
+	//builder.groups
+	if len(s.builder.groups) > 0 {
+		groups := make([]string, len(s.builder.groups))
+		for i, g := range s.builder.groups {
+			groups[i] = s.quoteName(g)
+		}
+		sqlBuffer.WriteString("GROUP BY " + strings.Join(groups, ", ") + " ")
+	}
+	//builder.havings
+	if len(s.builder.havings) > 0 {
+		sqlBuffer.WriteString("HAVING " + s.conditionsStr(s.builder.havings))
+	}
+}
+
+// PrepareSQL get prepare sql
+func (s *stmt) PrepareSQL() string {
+	if s.prepareSQL != "" {
+		return s.prepareSQL
+	}
+
+	return ""
+}
+
+// Bindings get prepare bind parameters
+func (s *stmt) Bindings() []interface{} {
+	return s.bindings
+}
+
+// insertID the strategy of the dialect, InsertIDNone if the query strategies have no auto increment pk
+func (s *stmt) insertID() InsertIDStrategy {
+	strategy := s.dialect.InsertID()
+	if !s.builder.model.isAuto && strategy != InsertIDResult {
+		return InsertIDNone
+	}
+	return strategy
+}
+
+// table the table of the builder, default the entity table
+func (s *stmt) table() string {
+	if s.builder.tableName != "" {
+		return s.builder.tableName
+	}
+	return s.builder.model.tableName
+}
+
+// quoteName quote the name qualified by dots and its alias, eg: user.id AS uid => `user`.`id` AS `uid`,
+// * is not quoted
+func (s *stmt) quoteName(name string) string {
+	name, alias := splitAlias(name)
+	parts := strings.Split(name, ".")
+	for i, p := range parts {
+		if p != "*" {
+			parts[i] = s.dialect.Quote(p)
+		}
+	}
+	if alias != "" {
+		return strings.Join(parts, ".") + " AS " + s.dialect.Quote(alias)
+	}
+	return strings.Join(parts, ".")
+}
+
+// selectName the quoted select field, a qualified column without alias is aliased table__column,
+// which the Collect maps to the entity
+func (s *stmt) selectName(name string) string {
+	if _, alias := splitAlias(name); alias == "" {
+		if i := strings.LastIndex(name, "."); i > 0 && name[i+1:] != "*" {
+			return s.quoteName(name + " AS " + strings.ReplaceAll(name, ".", "__"))
+		}
+	}
+	return s.quoteName(name)
+}
+
+// bind append the binding, return its placeholder, an Expression or a SubQuery is inlined
+func (s *stmt) bind(value interface{}) string {
+	switch v := value.(type) {
+	case *Expression:
+		return s.rawStr(v)
+	case *SubQuery:
+		return s.subStr(v)
+	}
+	s.bindings = append(s.bindings, value)
+	if s.raw {
+		return s.literal(value)
+	}
+	return s.dialect.Placeholder(len(s.bindings))
+}
+
+// subStr the parenthesised select of the subquery, its bindings follow the bindings before
+func (s *stmt) subStr(sub *SubQuery) string {
+	return "(" + s.subSelectStr(sub) + ")"
+}
+
+// subSelectStr the select of the subquery
+func (s *stmt) subSelectStr(sub *SubQuery) string {
+	if sub.err != nil && s.err == nil {
+		s.err = sub.err
+	}
+	sqlBuffer := new(strings.Builder)
+	s.withBuilder(sub.builder, func() {
+		s.selectStr(sqlBuffer)
+	})
+	return strings.TrimRight(sqlBuffer.String(), " ")
+}
+
+// unionStr the selects of the builder and its unions, without their orders and limits
+func (s *stmt) unionStr() string {
+	sqlBuffer := new(strings.Builder)
+	first := *s.builder
+	first.unions = nil
+	s.withBuilder(&first, func() {
+		s.queryStr(sqlBuffer, s.fieldsStr())
+	})
+	for _, u := range s.builder.unions {
+		if u.sub.err != nil && s.err == nil {
+			s.err = u.sub.err
+		}
+		if u.all {
+			sqlBuffer.WriteString("UNION ALL ")
+		} else {
+			sqlBuffer.WriteString("UNION ")
+		}
+		s.withBuilder(u.sub.builder, func() {
+			s.queryStr(sqlBuffer, s.fieldsStr())
+		})
+	}
+	return strings.TrimRight(sqlBuffer.String(), " ")
+}
+
+// withBuilder render by the builder in the closure
+func (s *stmt) withBuilder(b *Builder, closure func()) {
+	origin := s.builder
+	s.builder = b
+	defer func() {
+		s.builder = origin
+	}()
+	closure()
+}
+
+// rawStr the sql of the expression, the ? placeholders outside quotes are bound in order,
+// the missing bindings are nil
+func (s *stmt) rawStr(e *Expression) string {
+	sqlBuffer := new(strings.Builder)
+	var quote rune
+	n := 0
+	for _, r := range e.sql {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '\'' || r == '"' || r == '`':
+			quote = r
+		case r == '?':
+			var value interface{}
+			if n < len(e.bindings) {
+				value = e.bindings[n]
+			}
+			n++
+			sqlBuffer.WriteString(s.bind(value))
+			continue
+		}
+		sqlBuffer.WriteRune(r)
+	}
+	return sqlBuffer.String()
+}
+
+func (s *stmt) wheresStr() string {
+	if len(s.builder.wheres) == 0 {
+		return ""
+	}
+	return "WHERE " + s.conditionsStr(s.builder.wheres)
+}
+
+// conditionsStr join the conditions by AND or OR, groups are parenthesised,
+// the bindings follow the order of the conditions
+func (s *stmt) conditionsStr(wheres []*where) string {
+	sqlBuffer := new(strings.Builder)
+	for i, w := range wheres {
+		if i > 0 {
+			if w.or {
+				sqlBuffer.WriteString("OR ")
+			} else {
+				sqlBuffer.WriteString("AND ")
+			}
+		}
+		if w.raw {
+			sqlBuffer.WriteString("(" + s.rawStr(w.value.(*Expression)) + ") ")
+			continue
+		}
+		if w.group != nil {
+			sqlBuffer.WriteString("(" + strings.TrimRight(s.conditionsStr(w.group.wheres), " ") + ") ")
+			continue
+		}
+		sqlBuffer.WriteString(s.conditionStr(w))
+	}
+	return sqlBuffer.String()
+}
+
+// conditionStr the condition, IN expands the slice to placeholders, an empty IN is false,
+// BETWEEN binds the two values, IS NULL binds none
+func (s *stmt) conditionStr(w *where) string {
+	field := s.quoteName(w.field)
+	switch condition := strings.ToUpper(w.condition); condition {
+	case "IN", "NOT IN":
+		if sub, ok := w.value.(*SubQuery); ok {
+			return fmt.Sprintf("%s %s %s ", field, condition, s.bind(sub))
+		}
+		values := sliceValues(w.value)
+		if len(values) == 0 {
+			if condition == "IN" {
+				return "1 = 0 "
+			}
+			return "1 = 1 "
+		}
+		placeholders := make([]string, len(values))
+		for i, v := range values {
+			placeholders[i] = s.bind(v)
+		}
+		return fmt.Sprintf("%s %s (%s) ", field, condition, strings.Join(placeholders, ", "))
+	case "BETWEEN", "NOT BETWEEN":
+		values := sliceValues(w.value)
+		if len(values) != 2 {
+			values = append(values, nil, nil)[:2]
+		}
+		return fmt.Sprintf("%s %s %s AND %s ", field, condition, s.bind(values[0]), s.bind(values[1]))
+	case "EXISTS", "NOT EXISTS":
+		return fmt.Sprintf("%s %s ", condition, s.bind(w.value))
+	case "IS NULL", "IS NOT NULL":
+		return fmt.Sprintf("%s %s ", field, condition)
+	}
+	return fmt.Sprintf("%s %s %s ", field, w.condition, s.bind(w.value))
+}
+
+func (s *stmt) reset() {
+	s.prepareSQL = ""
+	s.bindings = make([]interface{}, 0)
+	s.op = 0
+	s.err = nil
+	s.raw = false
+}
+
+// interpolate build the sql with the bindings interpolated instead of the placeholders
+func (s *stmt) interpolate(raw bool) {
+	s.raw = raw
+}
+
+// literal the sql literal of the value, strings are quoted and escaped, times are formatted by FTimeDateTime
+func (s *stmt) literal(value interface{}) string {
+	if valuer, ok := value.(driver.Valuer); ok {
+		v, err := valuer.Value()
+		if err != nil {
+			return "NULL"
+		}
+		value = v
+	}
+	switch v := value.(type) {
+	case nil:
+		return "NULL"
+	case bool:
+		var literaler Literaler
+		if dialectAs(s.dialect, &literaler) {
+			return literaler.Bool(v)
+		}
+		return boolLiteral(v)
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return fmt.Sprint(v)
+	case float32:
+		return strconv.FormatFloat(float64(v), 'g', -1, 32)
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	case time.Time:
+		return "'" + v.Format(FTimeDateTime) + "'"
+	case []byte:
+		var literaler Literaler
+		if dialectAs(s.dialect, &literaler) {
+			return literaler.Bytes(v)
+		}
+		return hexLiteral(v)
+	case string:
+		return s.quoteString(v)
+	}
+	return s.quoteString(fmt.Sprint(value))
+}
+
+// quoteString quote the string by the dialect, the quote is doubled by default
+func (s *stmt) quoteString(str string) string {
+	var literaler Literaler
+	if dialectAs(s.dialect, &literaler) {
+		return literaler.QuoteString(str)
+	}
+	return quoteLiteral(str)
+}
+
+// splitAlias split "name AS alias" or "name alias"
+func splitAlias(name string) (string, string) {
+	fields := strings.Fields(name)
+	switch {
+	case len(fields) == 3 && strings.EqualFold(fields[1], "AS"):
+		return fields[0], fields[2]
+	case len(fields) == 2:
+		return fields[0], fields[1]
+	}
+	return name, ""
+}
+
+// tableAlias the name referring to the table, the alias if any
+func tableAlias(table string) string {
+	if _, alias := splitAlias(table); alias != "" {
+		return alias
+	}
+	return table
+}
+
+// sliceValues the elements of a slice or an array, []byte and other values are one element
+func sliceValues(value interface{}) []interface{} {
+	if value == nil {
+		return []interface{}{}
+	}
+	if _, ok := value.([]byte); ok {
+		return []interface{}{value}
+	}
+	rv := reflect.ValueOf(value)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return []interface{}{value}
+	}
+	values := make([]interface{}, rv.Len())
+	for i := range values {
+		values[i] = rv.Index(i).Interface()
+	}
+	return values
+}
+
+// timeValue the value to bind, time fields are formatted by the tag
+func timeValue(f Field) interface{} {
+	if f.fType != "time.Time" && f.fTagType != "Time" {
+		return f.value
+	}
+	switch f.fTagType {
+	case TagDate:
+		return f.value.(time.Time).Format(FTimeDate)
+	case TagTime:
+		return f.value.(time.Time).Format(FTimeTime)
+	case TagDateTime:
+		fallthrough
+	default:
+		return f.value.(time.Time).Format(FTimeDateTime)
+	}
+}
@@ -1,6 +1,7 @@
 package edb
 
 import (
+	"strings"
 	"testing"
 	"time"
 
@@ -9,9 +10,16 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
-func TestStmtSelect(t *testing.T) {
-	TestBoot(t)
+// newMysqlModel new a model of a mysql connection that is never opened, the stmt tests only build sql
+func newMysqlModel(t *testing.T, entity interface{}) *Model {
+	mgr := NewManager()
+	assert.True(t, mgr.AddConfig("my", &Config{Driver: "mysql", Host: "127.0.0.1", Port: "3306", Username: "root", Database: "test"}))
+	m, err := mgr.Conn("my").New(entity)
+	assert.Nil(t, err)
+	return m
+}
 
+func TestStmtSelect(t *testing.T) {
 	type User struct {
 		Id        int `type:"autoPk"`
 		Name      string
@@ -26,8 +34,7 @@ func TestStmtSelect(t *testing.T) {
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
 	}
-	m, err := New(u)
-	assert.Nil(t, err)
+	m := newMysqlModel(t, u)
 	stmt := m.stmt
 
 	stmt.SetOp(OPSelect)
@@ -100,8 +107,6 @@ func TestStmtSelect(t *testing.T) {
 }
 
 func TestStmtUpdate(t *testing.T) {
-	TestBoot(t)
-
 	type User struct {
 		Id        int `type:"autoPk"`
 		Name      string
@@ -116,8 +121,7 @@ func TestStmtUpdate(t *testing.T) {
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
 	}
-	m, err := New(u)
-	assert.Nil(t, err)
+	m := newMysqlModel(t, u)
 	stmt := m.stmt
 
 	stmt.SetOp(OPUpdate)
@@ -150,9 +154,22 @@ func TestStmtUpdate(t *testing.T) {
 	)
 }
 
-func TestStmtInsert(t *testing.T) {
-	TestBoot(t)
+// insertColumns the values of the columns of an insert statement, the column order follows the map of the entity
+func insertColumns(t *testing.T, stmt Stmt) map[string]interface{} {
+	sql := stmt.PrepareSQL()
+	open, close := strings.Index(sql, "("), strings.Index(sql, ")")
+	assert.True(t, open >= 0 && close > open)
+	columns := strings.Split(sql[open+1:close], ",")
+	assert.Len(t, stmt.Bindings(), len(columns))
+
+	values := make(map[string]interface{}, len(columns))
+	for i, column := range columns {
+		values[column] = stmt.Bindings()[i]
+	}
+	return values
+}
 
+func TestStmtInsert(t *testing.T) {
 	type User struct {
 		Id        int `type:"autoPk"`
 		Name      string
@@ -161,27 +178,24 @@ func TestStmtInsert(t *testing.T) {
 		UpdatedAt time.Time `type:"dateTime"`
 	}
 
+	now := time.Date(2021, 8, 9, 16, 22, 22, 0, time.Local)
 	u := &User{
 		Name:      "ttt",
 		Age:       111,
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
+		CreatedAt: now,
+		UpdatedAt: now,
 	}
-	m, err := New(u)
-	assert.Nil(t, err)
+	m := newMysqlModel(t, u)
 	stmt := m.stmt
 
 	stmt.SetOp(OPInsert)
-	stmt.Build()
+	assert.Nil(t, stmt.Build())
 
 	//map is an unordered , so the order of the fields cannot be determined
+	assert.Regexp(t, "^INSERT INTO `user` \\((`\\w+`,){3}`\\w+`\\) VALUES \\(\\?,\\?,\\?,\\?\\);$", stmt.PrepareSQL())
 	assert.Equal(t,
-		"INSERT INTO `user` (`name`,`age`,`created_at`,`updated_at`) VALUES (?,?,?,?) ;",
-		stmt.PrepareSQL(),
-	)
-	assert.Equal(t,
-		[]interface{}{"ttt", 111, "16:22:22", "2021-08-09 16:22:22"},
-		stmt.Bindings(),
+		map[string]interface{}{"`name`": "ttt", "`age`": 111, "`created_at`": "16:22:22", "`updated_at`": "2021-08-09 16:22:22"},
+		insertColumns(t, stmt),
 	)
 
 	//no Auto
@@ -196,25 +210,20 @@ func TestStmtInsert(t *testing.T) {
 		Id:        "sss",
 		Name:      "ttt",
 		Age:       111,
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
+		CreatedAt: now,
+		UpdatedAt: now,
 	}
-	m2, _ := New(u2)
+	m2 := newMysqlModel(t, u2)
 	m2.stmt.SetOp(OPInsert)
-	m2.stmt.Build()
-	assert.Equal(t,
-		"INSERT INTO `user` (`id`,`name`,`age`,`created_at`,`updated_at`) VALUES (?,?,?,?,?) ;",
-		m2.stmt.PrepareSQL(),
-	)
+	assert.Nil(t, m2.stmt.Build())
+	assert.Regexp(t, "^INSERT INTO `user2` \\((`\\w+`,){4}`\\w+`\\) VALUES \\(\\?,\\?,\\?,\\?,\\?\\);$", m2.stmt.PrepareSQL())
 	assert.Equal(t,
-		[]interface{}{"sss", "ttt", 111, "16:22:22", "2021-08-09 16:22:22"},
-		m2.stmt.Bindings(),
+		map[string]interface{}{"`id`": "sss", "`name`": "ttt", "`age`": 111, "`created_at`": "16:22:22", "`updated_at`": "2021-08-09 16:22:22"},
+		insertColumns(t, m2.stmt),
 	)
 }
 
 func TestStmtDelete(t *testing.T) {
-	TestBoot(t)
-
 	type User struct {
 		Id        int `type:"autoPk"`
 		Name      string
@@ -229,8 +238,7 @@ func TestStmtDelete(t *testing.T) {
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
 	}
-	m, err := New(u)
-	assert.Nil(t, err)
+	m := newMysqlModel(t, u)
 	stmt := m.stmt
 
 	stmt.SetOp(OPDelete)
@@ -0,0 +1,87 @@
+package edb
+
+import (
+	"encoding/hex"
+	"errors"
+	"strconv"
+)
+
+type (
+
+	// StmtPostgres postgres stmt
+	StmtPostgres struct {
+		stmt
+	}
+
+	// postgresDialect "ident" quoting, $n placeholders, INSERT ... RETURNING pk,
+	// postgres drivers do not support LastInsertId
+	postgresDialect struct{}
+
+	// sqlStateError the errors of lib/pq and pgx
+	sqlStateError interface {
+		SQLState() string
+	}
+)
+
+var _ Stmt = &StmtPostgres{}
+
+func (postgresDialect) Quote(ident string) string {
+	return `"` + ident + `"`
+}
+
+func (postgresDialect) Placeholder(n int) string {
+	return "$" + strconv.Itoa(n)
+}
+
+func (postgresDialect) Limit(limit, offset int64) string {
+	return limitOffset(limit, offset)
+}
+
+func (postgresDialect) DSN(c *Config) string {
+	return postgresDSN(c)
+}
+
+func (postgresDialect) InsertID() InsertIDStrategy {
+	return InsertIDReturning
+}
+
+func (p postgresDialect) Returning(pk string) string {
+	return "RETURNING " + p.Quote(pk)
+}
+
+func (postgresDialect) Lock(share bool, option string) string {
+	return lockClause(share, option)
+}
+
+func (postgresDialect) QuoteString(str string) string {
+	return quoteLiteral(str)
+}
+
+func (postgresDialect) Bool(b bool) string {
+	return boolLiteral(b)
+}
+
+// Bytes the bytea hex format, eg: '\x0aff'
+func (postgresDialect) Bytes(b []byte) string {
+	return `'\x` + hex.EncodeToString(b) + "'"
+}
+
+func (postgresDialect) ClassifyError(err error) error {
+	var e sqlStateError
+	if !errors.As(err, &e) {
+		return nil
+	}
+	switch e.SQLState() {
+	case "23505":
+		return ErrDuplicateKey
+	case "23503":
+		return ErrForeignKey
+	case "23502":
+		return ErrNotNull
+	case "40P01":
+		return ErrDeadlock
+	case "55P03":
+		return ErrLockTimeout
+	}
+	return nil
+}
@@ -0,0 +1,236 @@
+package edb
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newPostgresModel(t *testing.T, entity interface{}) *Model {
+	mgr := NewManager()
+	assert.True(t, mgr.AddConfig("pg", &Config{Driver: "postgres", Host: "127.0.0.1", Port: "5432", Username: "postgres", Database: "test"}))
+	m, err := mgr.Conn("pg").New(entity)
+	assert.Nil(t, err)
+	return m
+}
+
+func TestStmtPostgresSelect(t *testing.T) {
+	type User struct {
+		Id        int `type:"autoPk"`
+		Name      string
+		Age       int
+		CreatedAt time.Time `type:"dateTime"`
+		UpdatedAt time.Time `type:"dateTime"`
+	}
+
+	m := newPostgresModel(t, &User{})
+	stmt := m.stmt
+	_, ok := stmt.(*StmtPostgres)
+	assert.True(t, ok)
+
+	af := func(ecpectSql string, expectbindings []interface{}) {
+		assert.Nil(t, stmt.Build())
+		assert.Equal(t, ecpectSql, stmt.PrepareSQL())
+		assert.Equal(t, expectbindings, stmt.Bindings())
+		m.reset()
+	}
+
+	stmt.SetOp(OPSelect)
+	af(`SELECT * FROM "user" ;`, []interface{}{})
+
+	stmt.SetOp(OPSelect)
+	m.builder.Select([]string{"name"})
+	m.builder.WhereCondition("id", "=", 1)
+	m.builder.WhereCondition("age", ">", 20)
+	m.builder.OrderBy("id")
+	m.builder.OrderByDesc("name")
+	m.builder.limit = 2
+	af(`SELECT "name" FROM "user" WHERE "id" = $1 AND "age" > $2 ORDER BY "id" ASC , "name" DESC LIMIT 10 OFFSET 10 ;`, []interface{}{1, 20})
+
+	stmt.SetOp(OPSelect)
+	m.builder.limit = 1
+	m.builder.limitOffset = 0
+	af(`SELECT * FROM "user" LIMIT 1 ;`, []interface{}{})
+
+	stmt.SetOp(OPSelect)
+	m.builder.WhereGroup(func(q *Builder) {
+		q.WhereCondition("name", "=", "tom")
+		q.OrWhereGroup(func(q *Builder) {
+			q.WhereCondition("age", ">", 20)
+			q.WhereCondition("age", "<", 30)
+		})
+	})
+	m.builder.OrWhereCondition("id", "=", 1)
+	af(`SELECT * FROM "user" WHERE ("name" = $1 OR ("age" > $2 AND "age" < $3)) OR "id" = $4 ;`, []interface{}{"tom", 20, 30, 1})
+
+	stmt.SetOp(OPSelect)
+	m.In("id", []int{1, 2, 3}).NotIn("id", []int64{}).Between("age", 10, 20).IsNull("name").NotLike("name", "%t")
+	af(`SELECT * FROM "user" WHERE "id" IN ($1, $2, $3) AND 1 = 1 AND "age" BETWEEN $4 AND $5 AND "name" IS NULL AND "name" NOT LIKE $6 ;`, []interface{}{1, 2, 3, 10, 20, "%t"})
+
+	stmt.SetOp(OPSelect)
+	m.In("id", []string{}).OrWhere("name", "not in", [2]string{"a", "b"}).NotBetween("age", 1, 2).IsNotNull("age")
+	af(`SELECT * FROM "user" WHERE 1 = 0 OR "name" NOT IN ($1, $2) AND "age" NOT BETWEEN $3 AND $4 AND "age" IS NOT NULL ;`, []interface{}{"a", "b", 1, 2})
+
+	stmt.SetOp(OPSelect)
+	m.Select([]string{"name"}).SelectRaw("age + ? AS next_age", 1).WhereRaw("lower(name) = ? AND name != '?'", "tom").OrWhereRaw("age > ?", 20)
+	m.Eq("updated_at", Expr("created_at")).OrderByRaw("age = ? DESC", 30).OrderBy("id")
+	af(`SELECT "name", age + $1 AS next_age FROM "user" WHERE (lower(name) = $2 AND name != '?') OR (age > $3) AND "updated_at" = created_at ORDER BY age = $4 DESC , "id" ASC ;`, []interface{}{1, "tom", 20, 30})
+
+	stmt.SetOp(OPSelect)
+	m.Table("user AS u").Join("profile p", "p.user_id", "=", "u.id").LeftJoin("team", "team.id", "=", "u.team_id").CrossJoin("tag")
+	m.Select([]string{"u.id", "p.*", "team.name AS team", "age"}).Gt("u.age", 20).OrderByDesc("p.id")
+	af(`SELECT "u"."id" AS "u__id", "p".*, "team"."name" AS "team", "age" FROM "user" AS "u" INNER JOIN "profile" AS "p" ON "p"."user_id" = "u"."id" LEFT JOIN "team" ON "team"."id" = "u"."team_id" CROSS JOIN "tag" WHERE "u"."age" > $1 ORDER BY "p"."id" DESC ;`, []interface{}{20})
+
+	stmt.SetOp(OPSelect)
+	m.Select([]string{"name"}).SelectRaw("count(*) AS total").Gt("age", 1).GroupBy("name").HavingRaw("count(*) > ?", 2).OrHaving("name", "=", "tom").OrderByDesc("total")
+	m.builder.limit = 1
+	af(`SELECT "name", count(*) AS total FROM "user" WHERE "age" > $1 GROUP BY "name" HAVING (count(*) > $2) OR "name" = $3 ORDER BY "total" DESC LIMIT 10 OFFSET 0 ;`, []interface{}{1, 2, "tom"})
+
+	//the groups are counted
+	stmt.SetOp(OPCount)
+	m.Select([]string{"name"}).Gt("age", 1).GroupBy("name", "age").Having("age", ">", 2).OrderBy("name")
+	m.builder.limit = 2
+	af(`SELECT count(*) AS paginate FROM (SELECT "name" FROM "user" WHERE "age" > $1 GROUP BY "name", "age" HAVING "age" > $2 ) AS "paginate" ;`, []interface{}{1, 2})
+
+	stmt.SetOp(OPCount)
+	m.Gt("age", 1).builder.Aggregate("SUM", "age")
+	af(`SELECT SUM("age") AS aggregate FROM "user" WHERE "age" > $1 ;`, []interface{}{1})
+
+	type Order struct {
+		Id     int `type:"autoPk"`
+		UserId int
+		Amount int
+	}
+	orders := newPostgresModel(t, &Order{})
+	stmt.SetOp(OPSelect)
+	m.Eq("name", "tom").In("id", Sub(orders.Select([]string{"user_id"}).Gt("amount", 100).Lt("amount", 200))).Gt("age", 20)
+	m.WhereExists(Sub(orders.WhereRaw(`"order"."user_id" = "user"."id"`).Eq("amount", 1))).WhereNotExists(Sub(orders.Eq("amount", 2)))
+	af(`SELECT * FROM "user" WHERE "name" = $1 AND "id" IN (SELECT "user_id" FROM "order" WHERE "amount" > $2 AND "amount" < $3) AND "age" > $4 AND EXISTS (SELECT * FROM "order" WHERE ("order"."user_id" = "user"."id") AND "amount" = $5) AND NOT EXISTS (SELECT * FROM "order" WHERE "amount" = $6) ;`, []interface{}{"tom", 100, 200, 20, 1, 2})
+
+	stmt.SetOp(OPSelect)
+	m.FromSub(Sub(orders.Select([]string{"user_id"}).SelectRaw("sum(amount) AS total").Gt("amount", 1).GroupBy("user_id")), "t").Gt("t.total", 100)
+	af(`SELECT * FROM (SELECT "user_id", sum(amount) AS total FROM "order" WHERE "amount" > $1 GROUP BY "user_id") AS "t" WHERE "t"."total" > $2 ;`, []interface{}{1, 100})
+
+	stmt.SetOp(OPSelect)
+	m.Eq("age", 1).OrderBy("id").ForUpdate().SkipLocked()
+	m.builder.limit = 1
+	m.builder.limitOffset = 0
+	af(`SELECT * FROM "user" WHERE "age" = $1 ORDER BY "id" ASC LIMIT 1 FOR UPDATE SKIP LOCKED ;`, []interface{}{1})
+
+	stmt.SetOp(OPSelect)
+	m.ForShare().NoWait()
+	af(`SELECT * FROM "user" FOR SHARE NOWAIT ;`, []interface{}{})
+
+	//the count of Paginate is not locked
+	stmt.SetOp(OPCount)
+	m.ForUpdate()
+	af(`SELECT count(*) AS paginate FROM "user" ;`, []interface{}{})
+
+	stmt.SetOp(OPSelect)
+	m.Select([]string{"id", "name"}).Gt("age", 1).Union(orders.Select([]string{"id", "user_id"}).Lt("amount", 2).OrderBy("id"))
+	m.UnionAll(orders.Select([]string{"id", "user_id"}).Eq("amount", 3)).OrderByDesc("id")
+	m.builder.limit = 2
+	af(`SELECT * FROM (SELECT "id", "name" FROM "user" WHERE "age" > $1 UNION SELECT "id", "user_id" FROM "order" WHERE "amount" < $2 UNION ALL SELECT "id", "user_id" FROM "order" WHERE "amount" = $3) AS "u" ORDER BY "id" DESC LIMIT 10 OFFSET 10 ;`, []interface{}{1, 2, 3})
+
+	stmt.SetOp(OPCount)
+	m.Gt("age", 1).GroupBy("name").UnionAll(orders.Eq("amount", 3))
+	af(`SELECT count(*) AS paginate FROM (SELECT * FROM "user" WHERE "age" > $1 GROUP BY "name" UNION ALL SELECT * FROM "order" WHERE "amount" = $2) AS "u" ;`, []interface{}{1, 3})
+
+	recursive := newPostgresModel(t, &Order{})
+	stmt.SetOp(OPSelect)
+	m.With("big", orders.Gt("amount", 100)).WithRecursive("tree", orders.Select([]string{"id", "user_id"}).Eq("id", 1),
+		recursive.Table("order o").Join("tree", "o.user_id", "=", "tree.id").Select([]string{"o.id", "o.user_id"}).Lt("o.amount", 50))
+	m.Table("tree").Gt("id", 2)
+	af(`WITH RECURSIVE "big" AS (SELECT * FROM "order" WHERE "amount" > $1), "tree" AS (SELECT "id", "user_id" FROM "order" WHERE "id" = $2 UNION ALL SELECT "o"."id" AS "o__id", "o"."user_id" AS "o__user_id" FROM "order" AS "o" INNER JOIN "tree" ON "o"."user_id" = "tree"."id" WHERE "o"."amount" < $3) SELECT * FROM "tree" WHERE "id" > $4 ;`, []interface{}{100, 1, 50, 2})
+
+	stmt.SetOp(OPCount)
+	m.With("big", orders.Gt("amount", 100)).Table("big")
+	af(`WITH "big" AS (SELECT * FROM "order" WHERE "amount" > $1) SELECT count(*) AS paginate FROM "big" ;`, []interface{}{100})
+
+	//ToSQL keeps the builder
+	m.Eq("name", "tom").In("id", []int{1, 2}).WhereRaw("age > ?", 20).OrderBy("id")
+	sql, bindings, err := m.ToSQL()
+	assert.Nil(t, err)
+	assert.Equal(t, `SELECT * FROM "user" WHERE "name" = $1 AND "id" IN ($2, $3) AND (age > $4) ORDER BY "id" ASC ;`, sql)
+	assert.Equal(t, []interface{}{"tom", 1, 2, 20}, bindings)
+	sql, err = m.ToRawSQL()
+	assert.Nil(t, err)
+	assert.Equal(t, `SELECT * FROM "user" WHERE "name" = 'tom' AND "id" IN (1, 2) AND (age > 20) ORDER BY "id" ASC ;`, sql)
+	stmt.SetOp(OPSelect)
+	af(`SELECT * FROM "user" WHERE "name" = $1 AND "id" IN ($2, $3) AND (age > $4) ORDER BY "id" ASC ;`, []interface{}{"tom", 1, 2, 20})
+
+	//the error of the sub model
+	stmt.SetOp(OPSelect)
+	orders.lastErr = errors.New("sub err")
+	m.In("id", Sub(orders))
+	assert.EqualError(t, stmt.Build(), "sub err")
+	orders.lastErr = nil
+	m.reset()
+
+	stmt.SetOp(OPCount)
+	m.builder.WhereCondition("age", ">", 20)
+	m.builder.OrderBy("id")
+	m.builder.limit = 2
+	af(`SELECT count(*) AS paginate FROM "user" WHERE "age" > $1 ;`, []interface{}{20})
+}
+
+func TestStmtPostgresWrite(t *testing.T) {
+	type User struct {
+		Id   int `type:"autoPk"`
+		Name string
+	}
+
+	m := newPostgresModel(t, &User{Id: 3, Name: "tom"})
+	stmt := m.stmt
+
+	stmt.SetOp(OPInsert)
+	assert.Nil(t, stmt.Build())
+	assert.Equal(t, `INSERT INTO "user" ("name") VALUES ($1) RETURNING "id";`, stmt.PrepareSQL())
+	assert.Equal(t, []interface{}{"tom"}, stmt.Bindings())
+	assert.Equal(t, InsertIDReturning, stmt.insertID())
+	m.reset()
+
+	stmt.SetOp(OPUpdate)
+	m.builder.Update([]string{"name"})
+	assert.Nil(t, stmt.Build())
+	assert.Equal(t, `UPDATE "user" SET "name" = $1 WHERE "id" = $2 ;`, stmt.PrepareSQL())
+	assert.Equal(t, []interface{}{"tom", 3}, stmt.Bindings())
+	m.reset()
+
+	stmt.SetOp(OPUpdate)
+	m.builder.Update([]string{"name"})
+	m.builder.WhereCondition("name", "LIKE", "tom%")
+	assert.Nil(t, stmt.Build())
+	assert.Equal(t, `UPDATE "user" SET "name" = $1 WHERE "name" LIKE $2 ;`, stmt.PrepareSQL())
+	m.reset()
+
+	stmt.SetOp(OPUpdate)
+	m.builder.Update([]string{"name"})
+	m.builder.Set("views", Expr(`"views" + ?`, 1))
+	m.builder.Set("age", 20)
+	assert.Nil(t, stmt.Build())
+	assert.Equal(t, `UPDATE "user" SET "name" = $1,"views" = "views" + $2,"age" = $3 WHERE "id" = $4 ;`, stmt.PrepareSQL())
+	assert.Equal(t, []interface{}{"tom", 1, 20, 3}, stmt.Bindings())
+	m.reset()
+
+	stmt.SetOp(OPUpdate)
+	assert.EqualError(t, stmt.Build(), "edb StmtPostgres.Build err: OPUpdate no updated fields")
+	m.reset()
+
+	stmt.SetOp(OPDelete)
+	assert.Nil(t, stmt.Build())
+	assert.Equal(t, `DELETE FROM "user" WHERE "id" = $1 ;`, stmt.PrepareSQL())
+	assert.Equal(t, []interface{}{3}, stmt.Bindings())
+
+	//no auto pk, Insert returns 0
+	type Tag struct {
+		Code string `type:"pk"`
+	}
+	m2 := newPostgresModel(t, &Tag{Code: "go"})
+	m2.stmt.SetOp(OPInsert)
+	assert.Nil(t, m2.stmt.Build())
+	assert.Equal(t, `INSERT INTO "tag" ("code") VALUES ($1);`, m2.stmt.PrepareSQL())
+	assert.Equal(t, InsertIDNone, m2.stmt.insertID())
+}
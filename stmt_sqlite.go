@@ -0,0 +1,55 @@
+package edb
+
+import "strings"
+
+type (
+
+	// StmtSqlite sqlite stmt
+	StmtSqlite struct {
+		stmt
+	}
+
+	// sqliteDialect "ident" quoting, ? placeholders, LastInsertId
+	sqliteDialect struct{}
+)
+
+var _ Stmt = &StmtSqlite{}
+
+func (sqliteDialect) Quote(ident string) string {
+	return `"` + ident + `"`
+}
+
+func (sqliteDialect) Placeholder(n int) string {
+	return "?"
+}
+
+func (sqliteDialect) Limit(limit, offset int64) string {
+	return limitOffset(limit, offset)
+}
+
+func (sqliteDialect) DSN(c *Config) string {
+	return sqliteDSN(c)
+}
+
+func (sqliteDialect) InsertID() InsertIDStrategy {
+	return InsertIDResult
+}
+
+func (sqliteDialect) Returning(pk string) string {
+	return ""
+}
+
+func (sqliteDialect) ClassifyError(err error) error {
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "UNIQUE constraint failed"):
+		return ErrDuplicateKey
+	case strings.Contains(msg, "FOREIGN KEY constraint failed"):
+		return ErrForeignKey
+	case strings.Contains(msg, "NOT NULL constraint failed"):
+		return ErrNotNull
+	case strings.Contains(msg, "database is locked"):
+		return ErrLockTimeout
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package edb
+
+import (
+	"encoding/hex"
+	"errors"
+	"strconv"
+)
+
+type (
+
+	// StmtSqlserver sql server stmt
+	StmtSqlserver struct {
+		stmt
+	}
+
+	// sqlserverDialect [ident] quoting, @pN placeholders, OFFSET FETCH ordered by the pk
+	// if there is no order, INSERT ... OUTPUT INSERTED.pk, LastInsertId is not supported
+	sqlserverDialect struct{}
+
+	// sqlErrorNumberError the errors of go-mssqldb
+	sqlErrorNumberError interface {
+		SQLErrorNumber() int32
+	}
+)
+
+var _ Stmt = &StmtSqlserver{}
+
+func (sqlserverDialect) Quote(ident string) string {
+	return "[" + ident + "]"
+}
+
+func (sqlserverDialect) Placeholder(n int) string {
+	return "@p" + strconv.Itoa(n)
+}
+
+func (sqlserverDialect) Limit(limit, offset int64) string {
+	return "OFFSET " + strconv.FormatInt(offset, 10) + " ROWS FETCH NEXT " + strconv.FormatInt(limit, 10) + " ROWS ONLY"
+}
+
+func (sqlserverDialect) LimitRequiresOrder() bool {
+	return true
+}
+
+func (sqlserverDialect) DSN(c *Config) string {
+	return sqlserverDSN(c)
+}
+
+func (sqlserverDialect) InsertID() InsertIDStrategy {
+	return InsertIDOutput
+}
+
+func (s sqlserverDialect) Returning(pk string) string {
+	return "OUTPUT INSERTED." + s.Quote(pk)
+}
+
+// With sql server has no RECURSIVE keyword
+func (sqlserverDialect) With(recursive bool) string {
+	return "WITH"
+}
+
+func (sqlserverDialect) Savepoint(name string) string {
+	return "SAVE TRANSACTION " + name
+}
+
+// ReleaseSavepoint sql server has no release, the savepoint lives until the transaction ends
+func (sqlserverDialect) ReleaseSavepoint(name string) string {
+	return ""
+}
+
+func (sqlserverDialect) RollbackToSavepoint(name string) string {
+	return "ROLLBACK TRANSACTION " + name
+}
+
+func (sqlserverDialect) QuoteString(str string) string {
+	return quoteLiteral(str)
+}
+
+// Bool 1 or 0, sql server has no boolean literals
+func (sqlserverDialect) Bool(b bool) string {
+	if b {
+		return "1"
+	}
+	return "0"
+}
+
+// Bytes the binary constant, eg: 0x0aff
+func (sqlserverDialect) Bytes(b []byte) string {
+	return "0x" + hex.EncodeToString(b)
+}
+
+func (sqlserverDialect) ClassifyError(err error) error {
+	var e sqlErrorNumberError
+	if !errors.As(err, &e) {
+		return nil
+	}
+	switch e.SQLErrorNumber() {
+	case 2601, 2627:
+		return ErrDuplicateKey
+	case 547:
+		return ErrForeignKey
+	case 515:
+		return ErrNotNull
+	case 1205:
+		return ErrDeadlock
+	case 1222:
+		return ErrLockTimeout
+	}
+	return nil
+}
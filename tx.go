@@ -0,0 +1,215 @@
+package edb
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+type (
+
+	// Tx transaction, models created by Tx.New run on the same *sql.Tx
+	Tx struct {
+		manager *Manager
+		ctx     context.Context
+		// tx nil in the transaction of a dry run
+		tx          *sql.Tx
+		connectName string
+		// savepoints counter shared by the nested transactions
+		savepoints *int
+		// release finish counting the transaction in progress
+		release func()
+	}
+
+	// txKey the context key of the transaction in progress
+	txKey struct{}
+)
+
+// newTx new transaction, its context carries it, TransactionContext with the context nests a savepoint
+func newTx(mgr *Manager, ctx context.Context, sqlTx *sql.Tx, connectName string) *Tx {
+	tx := &Tx{
+		manager:     mgr,
+		tx:          sqlTx,
+		connectName: connectName,
+		savepoints:  new(int),
+	}
+	tx.ctx = context.WithValue(ctx, txKey{}, tx)
+	return tx
+}
+
+// contextTx the transaction in progress carried by ctx
+func contextTx(ctx context.Context) (*Tx, bool) {
+	tx, ok := ctx.Value(txKey{}).(*Tx)
+	return tx, ok
+}
+
+var _ executor = &Tx{}
+
+// New new model bound to the transaction
+func (tx *Tx) New(entity interface{}) (m *Model, err error) {
+	m, err = newModel(tx.manager, entity, tx.connectName)
+	if m != nil {
+		m.tx = tx
+	}
+	return
+}
+
+// Exec exec in the transaction
+func (tx *Tx) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return tx.ExecContext(tx.ctx, query, args...)
+}
+
+// ExecContext exec in the transaction with context
+func (tx *Tx) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	if result, ok, err := tx.manager.dryRun(tx.connectName, query, args); ok {
+		return result, err
+	}
+	sqlResult, err := tx.tx.ExecContext(ctx, query, args...)
+	return sqlResult, tx.classifyError(err)
+}
+
+// Query query in the transaction
+func (tx *Tx) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	return tx.QueryContext(tx.ctx, query, args...)
+}
+
+// QueryContext query in the transaction with context
+func (tx *Tx) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	if tx.tx == nil {
+		//the transaction of a dry run reads from the primary
+		p, err := tx.manager.connect.pool(tx.connectName)
+		if err != nil {
+			return nil, err
+		}
+		return p.QueryContext(ctx, query, args...)
+	}
+	sqlRows, err := tx.tx.QueryContext(ctx, query, args...)
+	return sqlRows, tx.classifyError(err)
+}
+
+// queryContext QueryContext, the transaction is counted in progress until it finishes
+func (tx *Tx) queryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, func(), error) {
+	if tx.tx == nil {
+		//the transaction of a dry run reads from the primary
+		p, err := tx.manager.connect.pool(tx.connectName)
+		if err != nil {
+			return nil, nil, err
+		}
+		return p.queryContext(ctx, query, args...)
+	}
+	sqlRows, err := tx.QueryContext(ctx, query, args...)
+	return sqlRows, func() {}, err
+}
+
+// QueryCollect query in the transaction and return *Collect
+func (tx *Tx) QueryCollect(query string, args ...interface{}) (*Collect, error) {
+	sqlRows, err := tx.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	return &Collect{
+		sqlRows: sqlRows,
+	}, nil
+}
+
+// Context the context of the transaction, TransactionContext with it creates a savepoint
+// in the transaction instead of beginning another one
+//
+// Example usage:
+//
+// (
+// 	edb.Transaction(func(tx *edb.Tx) error {
+// 		//SAVEPOINT sp_1
+// 		return edb.TransactionContext(tx.Context(), func(tx *edb.Tx) error {
+// 			//...
+// 		})
+// 	})
+// )
+func (tx *Tx) Context() context.Context {
+	return tx.ctx
+}
+
+// Tx *sql.Tx, nil in the transaction of a dry run
+func (tx *Tx) Tx() *sql.Tx {
+	return tx.tx
+}
+
+// Transaction nested transaction, create a SAVEPOINT sp_N, release it when the
+// closure returns nil, rollback to it when the closure returns an error or panics,
+// the outer transaction is not affected
+func (tx *Tx) Transaction(closure func(tx *Tx) error) error {
+	if tx.tx == nil {
+		//no savepoint is recorded in a dry run
+		return runTransaction(tx, closure, func() error { return nil }, func() error { return nil })
+	}
+	*tx.savepoints++
+	savepoint := fmt.Sprintf("sp_%d", *tx.savepoints)
+	create, release, rollback := "SAVEPOINT "+savepoint, "RELEASE SAVEPOINT "+savepoint, "ROLLBACK TO SAVEPOINT "+savepoint
+	if d, ok := LookupDialect(tx.manager.connect.driver(tx.connectName)); ok {
+		var sp Savepointer
+		if dialectAs(d, &sp) {
+			create, release, rollback = sp.Savepoint(savepoint), sp.ReleaseSavepoint(savepoint), sp.RollbackToSavepoint(savepoint)
+		}
+	}
+	if _, err := tx.Exec(create); err != nil {
+		return fmt.Errorf("edb Tx.Transaction err: %w", err)
+	}
+
+	return runTransaction(&Tx{manager: tx.manager, ctx: tx.ctx, tx: tx.tx, connectName: tx.connectName, savepoints: tx.savepoints}, closure,
+		func() error {
+			if release == "" {
+				return nil
+			}
+			if _, err := tx.Exec(release); err != nil {
+				return fmt.Errorf("edb Tx.Release err: %w", err)
+			}
+			return nil
+		},
+		func() error {
+			_, err := tx.Exec(rollback)
+			return err
+		},
+	)
+}
+
+// commit commit the transaction
+func (tx *Tx) commit() error {
+	defer tx.release()
+	if err := tx.tx.Commit(); err != nil {
+		return fmt.Errorf("edb Tx.Commit err: %w", tx.classifyError(err))
+	}
+	return nil
+}
+
+// classifyError classify the driver error by the dialect of the connection
+func (tx *Tx) classifyError(err error) error {
+	if err == nil {
+		return nil
+	}
+	return classifyError(tx.manager.connect.driver(tx.connectName), err)
+}
+
+// rollback rollback the transaction
+func (tx *Tx) rollback() error {
+	defer tx.release()
+	return tx.tx.Rollback()
+}
+
+// runTransaction run closure, commit when it returns nil, rollback when it returns an error or panics
+func runTransaction(tx *Tx, closure func(tx *Tx) error, commit func() error, rollback func() error) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			rollback()
+			panic(p)
+		}
+	}()
+
+	if err = closure(tx); err != nil {
+		if rbErr := rollback(); rbErr != nil {
+			return fmt.Errorf("edb Tx.Rollback err: %s, closure err: %w", rbErr.Error(), err)
+		}
+		return err
+	}
+
+	return commit()
+}
@@ -0,0 +1,314 @@
+package edb
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransaction(t *testing.T) {
+	testBoot(t)
+
+	type User struct {
+		Id        int `type:"autoPk"`
+		Name      string
+		Age       int
+		CreatedAt time.Time `type:"dateTime"`
+		UpdatedAt time.Time `type:"dateTime"`
+	}
+
+	m, err := New(&User{})
+	assert.Nil(t, err)
+
+	//commit
+	err = Transaction(func(tx *Tx) error {
+		m1, err := tx.New(&User{Name: "tom", Age: 1})
+		if err != nil {
+			return err
+		}
+		if _, err := m1.Insert(); err != nil {
+			return err
+		}
+		m2, err := tx.New(&User{Id: 1, Name: "tom", Age: 2})
+		if err != nil {
+			return err
+		}
+		_, err = m2.Update([]string{"age"})
+		return err
+	})
+	assert.Nil(t, err)
+	e, err := m.Eq("id", 1).First()
+	assert.Nil(t, err)
+	assert.Equal(t, 2, e.(*User).Age)
+
+	//rollback on error
+	err = Transaction(func(tx *Tx) error {
+		m1, err := tx.New(&User{Name: "tom2"})
+		if err != nil {
+			return err
+		}
+		if _, err := m1.Insert(); err != nil {
+			return err
+		}
+		return errors.New("stop")
+	})
+	assert.EqualError(t, err, "stop")
+	e2, err := m.Eq("name", "tom2").First()
+	assert.Nil(t, err)
+	assert.Nil(t, e2)
+
+	//rollback on panic
+	assert.PanicsWithValue(t, "stop", func() {
+		Transaction(func(tx *Tx) error {
+			m1, _ := tx.New(&User{Name: "tom3"})
+			m1.Insert()
+			panic("stop")
+		})
+	})
+	e3, err := m.Eq("name", "tom3").First()
+	assert.Nil(t, err)
+	assert.Nil(t, e3)
+}
+
+func TestModelTransaction(t *testing.T) {
+	testBoot(t)
+
+	type User struct {
+		Id        int `type:"autoPk"`
+		Name      string
+		Age       int
+		CreatedAt time.Time `type:"dateTime"`
+		UpdatedAt time.Time `type:"dateTime"`
+	}
+
+	m, err := New(&User{Name: "tom"})
+	assert.Nil(t, err)
+
+	err = m.Transaction(func(tx *Tx) error {
+		assert.Equal(t, tx, m.tx)
+		if _, err := m.Insert(); err != nil {
+			return err
+		}
+		//visible inside the transaction
+		e, err := m.Eq("name", "tom").First()
+		assert.NotNil(t, e)
+		if err != nil {
+			return err
+		}
+		return errors.New("stop")
+	})
+	assert.EqualError(t, err, "stop")
+	assert.Nil(t, m.tx)
+
+	e, err := m.Eq("name", "tom").First()
+	assert.Nil(t, err)
+	assert.Nil(t, e)
+}
+
+func TestNestedTransaction(t *testing.T) {
+	testBoot(t)
+
+	type User struct {
+		Id        int `type:"autoPk"`
+		Name      string
+		Age       int
+		CreatedAt time.Time `type:"dateTime"`
+		UpdatedAt time.Time `type:"dateTime"`
+	}
+
+	m, err := New(&User{})
+	assert.Nil(t, err)
+
+	insert := func(tx *Tx, name string) error {
+		m, err := tx.New(&User{Name: name})
+		if err != nil {
+			return err
+		}
+		_, err = m.Insert()
+		return err
+	}
+
+	err = Transaction(func(tx *Tx) error {
+		if err := insert(tx, "outer"); err != nil {
+			return err
+		}
+		//rollback to sp_1 only
+		err := tx.Transaction(func(tx *Tx) error {
+			if err := insert(tx, "inner1"); err != nil {
+				return err
+			}
+			return errors.New("stop")
+		})
+		assert.EqualError(t, err, "stop")
+
+		//release sp_2
+		return tx.Transaction(func(tx *Tx) error {
+			if err := insert(tx, "inner2"); err != nil {
+				return err
+			}
+			//sp_3
+			return tx.Transaction(func(tx *Tx) error {
+				return insert(tx, "inner3")
+			})
+		})
+	})
+	assert.Nil(t, err)
+
+	c, err := m.OrderBy("id").Get()
+	assert.Nil(t, err)
+	names := make([]string, 0)
+	for c.Next() {
+		names = append(names, c.Item().(*User).Name)
+	}
+	assert.Equal(t, []string{"outer", "inner2", "inner3"}, names)
+
+	//model bound to a transaction uses a savepoint
+	m2, err := New(&User{Name: "model"})
+	assert.Nil(t, err)
+	err = m2.Transaction(func(tx *Tx) error {
+		if _, err := m2.Insert(); err != nil {
+			return err
+		}
+		m2.Transaction(func(tx *Tx) error {
+			m2.Insert()
+			return errors.New("stop")
+		})
+		return nil
+	})
+	assert.Nil(t, err)
+	c2, err := m.Eq("name", "model").Get()
+	assert.Nil(t, err)
+	count := 0
+	for c2.Next() {
+		count++
+	}
+	assert.Equal(t, 1, count)
+}
+
+func TestTransactionPaginate(t *testing.T) {
+	testBoot(t)
+
+	type User struct {
+		Id        int `type:"autoPk"`
+		Name      string
+		Age       int
+		CreatedAt time.Time `type:"dateTime"`
+		UpdatedAt time.Time `type:"dateTime"`
+	}
+
+	err := Transaction(func(tx *Tx) error {
+		for i := 0; i < 12; i++ {
+			m, err := tx.New(&User{Name: "tom", Age: i})
+			if err != nil {
+				return err
+			}
+			if _, err := m.Insert(); err != nil {
+				return err
+			}
+		}
+
+		m, err := tx.New(&User{})
+		if err != nil {
+			return err
+		}
+		//the count runs before the rows of the page are read on the same connection
+		c, err := m.Gte("age", 2).OrderBy("age").Paginate(2, 4)
+		if err != nil {
+			return err
+		}
+		assert.Equal(t, int64(10), c.Total())
+		ages := make([]int, 0)
+		for c.Next() {
+			ages = append(ages, c.Item().(*User).Age)
+		}
+		assert.Equal(t, []int{6, 7, 8, 9}, ages)
+
+		//the transaction is still usable
+		count, err := m.Count()
+		assert.Equal(t, int64(12), count)
+		return err
+	})
+	assert.Nil(t, err)
+}
+
+func TestTransactionContextNested(t *testing.T) {
+	testBoot(t)
+
+	type User struct {
+		Id        int `type:"autoPk"`
+		Name      string
+		Age       int
+		CreatedAt time.Time `type:"dateTime"`
+		UpdatedAt time.Time `type:"dateTime"`
+	}
+
+	insert := func(tx *Tx, name string) error {
+		m, err := tx.New(&User{Name: name})
+		if err != nil {
+			return err
+		}
+		_, err = m.Insert()
+		return err
+	}
+
+	err := Transaction(func(tx *Tx) error {
+		if err := insert(tx, "outer"); err != nil {
+			return err
+		}
+		//SAVEPOINT sp_1 on the same *sql.Tx, rolled back alone
+		err := TransactionContext(tx.Context(), func(inner *Tx) error {
+			assert.Same(t, tx.Tx(), inner.Tx())
+			if err := insert(inner, "inner1"); err != nil {
+				return err
+			}
+			return errors.New("stop")
+		})
+		assert.EqualError(t, err, "stop")
+
+		//a model with the context of the transaction nests as well
+		m, err := New(&User{Name: "inner2"})
+		if err != nil {
+			return err
+		}
+		return m.WithContext(tx.Context()).Transaction(func(inner *Tx) error {
+			assert.Same(t, tx.Tx(), inner.Tx())
+			_, err := m.Insert()
+			return err
+		})
+	})
+	assert.Nil(t, err)
+
+	m, err := New(&User{})
+	assert.Nil(t, err)
+	c, err := m.OrderBy("id").Get()
+	assert.Nil(t, err)
+	names := make([]string, 0)
+	for c.Next() {
+		names = append(names, c.Item().(*User).Name)
+	}
+	assert.Equal(t, []string{"outer", "inner2"}, names)
+
+	//another connection or manager begins its own transaction
+	mgr := NewManager()
+	assert.True(t, mgr.AddConfig("default", &Config{Driver: "sqlite3", Database: ":memory:"}))
+	assert.True(t, mgr.AddConfig("other", &Config{Driver: "sqlite3", Database: ":memory:"}))
+	assert.Nil(t, mgr.Open("default"))
+	assert.Nil(t, mgr.Open("other"))
+	defer mgr.Close()
+	err = mgr.Transaction(func(tx *Tx) error {
+		err := mgr.Conn("other").TransactionContext(tx.Context(), func(other *Tx) error {
+			assert.NotSame(t, tx.Tx(), other.Tx())
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		return TransactionContext(tx.Context(), func(other *Tx) error {
+			assert.NotSame(t, tx.Tx(), other.Tx())
+			return nil
+		})
+	})
+	assert.Nil(t, err)
+}
@@ -20,6 +20,10 @@ type (
 		SetBuilder(*Builder)
 		// SetOp
 		SetOp(operateType)
+		// insertID how Insert gets the id of the inserted row
+		insertID() InsertIDStrategy
+		// interpolate build the sql with the bindings interpolated instead of the placeholders
+		interpolate(bool)
 		reset()
 	}
 
@@ -27,6 +31,8 @@ type (
 	Query interface {
 		// Select
 		Select([]string) *Model
+		// SelectRaw("count(*) AS total")
+		SelectRaw(sql string, bindings ...interface{}) *Model
 		// Eq("name", "tom") => name='tom'
 		Eq(field string, value interface{}) *Model
 		// Neq("name", "tom") => name !='tom'
@@ -41,21 +47,113 @@ type (
 		Gte(field string, value interface{}) *Model
 		// Like("name", "%sss") => `name` LIKE '%sss'
 		Like(field string, value interface{}) *Model
+		// NotLike("name", "%sss") => `name` NOT LIKE '%sss'
+		NotLike(field string, value interface{}) *Model
+		// In("id", []int{1, 2}) => `id` IN (1, 2)
+		In(field string, values interface{}) *Model
+		// NotIn("id", []int{1, 2}) => `id` NOT IN (1, 2)
+		NotIn(field string, values interface{}) *Model
+		// Between("age", 1, 10) => `age` BETWEEN 1 AND 10
+		Between(field string, lo interface{}, hi interface{}) *Model
+		// NotBetween("age", 1, 10) => `age` NOT BETWEEN 1 AND 10
+		NotBetween(field string, lo interface{}, hi interface{}) *Model
+		// IsNull("name") => `name` IS NULL
+		IsNull(field string) *Model
+		// IsNotNull("name") => `name` IS NOT NULL
+		IsNotNull(field string) *Model
+		// OrEq("name", "tom") => OR `name` = 'tom'
+		OrEq(field string, value interface{}) *Model
+		// OrNeq("name", "tom") => OR `name` != 'tom'
+		OrNeq(field string, value interface{}) *Model
+		// OrLt("age", 1) => OR `age` < 1
+		OrLt(field string, value interface{}) *Model
+		// OrLte("age", 1) => OR `age` <= 1
+		OrLte(field string, value interface{}) *Model
+		// OrGt("age", 1) => OR `age` > 1
+		OrGt(field string, value interface{}) *Model
+		// OrGte("age", 1) => OR `age` >= 1
+		OrGte(field string, value interface{}) *Model
+		// OrLike("name", "%sss") => OR `name` LIKE '%sss'
+		OrLike(field string, value interface{}) *Model
+		// Where("age", ">", 1) => `age` > 1
+		Where(field string, condition string, value interface{}) *Model
+		// OrWhere("age", ">", 1) => OR `age` > 1
+		OrWhere(field string, condition string, value interface{}) *Model
+		// WhereRaw("score > ?", 1) => (score > 1)
+		WhereRaw(sql string, bindings ...interface{}) *Model
+		// OrWhereRaw("score > ?", 1) => OR (score > 1)
+		OrWhereRaw(sql string, bindings ...interface{}) *Model
+		// WhereExists(edb.Sub(m)) => EXISTS (SELECT ...)
+		WhereExists(sub *SubQuery) *Model
+		// WhereNotExists(edb.Sub(m)) => NOT EXISTS (SELECT ...)
+		WhereNotExists(sub *SubQuery) *Model
+		// OrWhereExists(edb.Sub(m)) => OR EXISTS (SELECT ...)
+		OrWhereExists(sub *SubQuery) *Model
+		// WhereGroup(func(q *Builder){...}) => AND (...)
+		WhereGroup(func(q *Builder)) *Model
+		// OrWhereGroup(func(q *Builder){...}) => OR (...)
+		OrWhereGroup(func(q *Builder)) *Model
+		// Table("user AS u") => FROM `user` AS `u`
+		Table(table string) *Model
+		// FromSub(edb.Sub(m), "t") => FROM (SELECT ...) AS `t`
+		FromSub(sub *SubQuery, alias string) *Model
+		// With("recent", sub) => WITH `recent` AS (SELECT ...)
+		With(name string, sub *Model) *Model
+		// WithRecursive("tree", anchor, recursive) => WITH RECURSIVE `tree` AS (SELECT ... UNION ALL SELECT ...)
+		WithRecursive(name string, anchor *Model, recursive *Model) *Model
+		// Union(other) => SELECT * FROM (SELECT ... UNION SELECT ...) AS `u`
+		Union(other *Model) *Model
+		// UnionAll(other) => SELECT * FROM (SELECT ... UNION ALL SELECT ...) AS `u`
+		UnionAll(other *Model) *Model
+		// Join("profile", "profile.user_id", "=", "user.id") => INNER JOIN `profile` ON `profile`.`user_id` = `user`.`id`
+		Join(table string, first string, operator string, second string) *Model
+		// LeftJoin("profile", "profile.user_id", "=", "user.id") => LEFT JOIN ...
+		LeftJoin(table string, first string, operator string, second string) *Model
+		// RightJoin("profile", "profile.user_id", "=", "user.id") => RIGHT JOIN ...
+		RightJoin(table string, first string, operator string, second string) *Model
+		// CrossJoin("tag") => CROSS JOIN `tag`
+		CrossJoin(table string) *Model
+		// GroupBy("name", "age") => GROUP BY `name`, `age`
+		GroupBy(fields ...string) *Model
+		// Having("total", ">", 1) => HAVING `total` > 1
+		Having(field string, condition string, value interface{}) *Model
+		// OrHaving("total", ">", 1) => OR `total` > 1
+		OrHaving(field string, condition string, value interface{}) *Model
+		// HavingRaw("count(*) > ?", 1) => HAVING (count(*) > 1)
+		HavingRaw(sql string, bindings ...interface{}) *Model
 		// Order ASC sort
 		OrderBy(string) *Model
 		// OrderByDesc DESC sort
 		OrderByDesc(string) *Model
+		// OrderByRaw("FIELD(status, ?, ?)", 1, 2)
+		OrderByRaw(sql string, bindings ...interface{}) *Model
+		// Set("views", edb.Expr("`views` + 1")) => SET `views` = `views` + 1
+		Set(field string, value interface{}) *Model
+		// ForUpdate() => FOR UPDATE
+		ForUpdate() *Model
+		// ForShare() => FOR SHARE
+		ForShare() *Model
+		// SkipLocked() => FOR UPDATE SKIP LOCKED
+		SkipLocked() *Model
+		// NoWait() => FOR UPDATE NOWAIT
+		NoWait() *Model
 		Get() (*Collect, error)
 		First() (interface{}, error)
 		Paginate(page int64, pageSize int64) (*Collect, error)
+		Count() (int64, error)
+		Sum(field string) (float64, error)
+		Avg(field string) (float64, error)
+		Max(field string) (float64, error)
+		Min(field string) (float64, error)
 		Delete() (rowAffected int64, err error)
 		Insert() (id int64, err error)
 		Update([]string) (rowAffected int64, err error)
-		// todo
-		// Transaction(conn string, closure func()) error
+		Transaction(func(tx *Tx) error) error
 		Query(string, ...interface{}) (*sql.Rows, error)
 		Exec(string, ...interface{}) (sql.Result, error)
-		// todo
-		// ToSQL() string
+		// ToSQL the select sql and bindings
+		ToSQL() (string, []interface{}, error)
+		// ToRawSQL the select sql with the bindings interpolated
+		ToRawSQL() (string, error)
 	}
 )